@@ -0,0 +1,249 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/journal"
+	"github.com/ewok/ffiii-rate-updater/internal/limit"
+	"github.com/ewok/ffiii-rate-updater/internal/notify"
+)
+
+// httpClient is shared by the Firefly III client and the providers, so connections are reused.
+// Timeouts are set per request through the context.
+var httpClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+	return &http.Client{Transport: transport}
+}
+
+const (
+	defaultFetchConcurrency = 4
+	defaultSendConcurrency  = 1
+)
+
+// applyRateLimits wraps the transport of the shared HTTP client with the per-host limits
+// of the rate_limits section of the configuration.
+func applyRateLimits() error {
+	var limits []limit.HostLimit
+	if err := viper.UnmarshalKey("rate_limits", &limits); err != nil {
+		return fmt.Errorf("failed to read rate limits: %v", err)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	transport, err := limit.NewTransport(newHTTPClient().Transport, limits)
+	if err != nil {
+		return fmt.Errorf("invalid rate limits: %v", err)
+	}
+	httpClient.Transport = transport
+	return nil
+}
+
+// readConcurrency returns the concurrency limit of the kind ("fetch" or "send"), read from
+// the --<kind>-concurrency flag or the concurrency section of the configuration.
+func readConcurrency(cmd *cobra.Command, kind string) (int, error) {
+	value, _ := cmd.Flags().GetInt(kind + "-concurrency")
+	if !cmd.Flags().Changed(kind+"-concurrency") && viper.IsSet("concurrency."+kind) {
+		value = viper.GetInt("concurrency." + kind)
+	}
+	if value < 1 {
+		return 0, fmt.Errorf("%s concurrency must be at least 1, got %d", kind, value)
+	}
+	return value, nil
+}
+
+// newProvider creates the configured rate provider with its settings from the providers section.
+func newProvider() (exchange.Provider, error) {
+	return newNamedProvider(viper.GetString("provider"))
+}
+
+// newNamedProvider creates the rate provider with the given name and its settings from the providers section.
+func newNamedProvider(name string) (exchange.Provider, error) {
+	config, err := readProviderConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.NewProvider(name, config)
+}
+
+// readProviderConfig reads the settings of the named provider from the providers section.
+func readProviderConfig(name string) (exchange.ProviderConfig, error) {
+	var config exchange.ProviderConfig
+	if err := viper.UnmarshalKey("providers."+name, &config); err != nil {
+		return config, fmt.Errorf("failed to read settings of provider %s: %v", name, err)
+	}
+	for _, value := range config.Headers {
+		addHeaderSecret(value)
+	}
+	config.HTTPClient = httpClient
+	return config, nil
+}
+
+// readCurrencyMappings reads the currency_map section of the configuration.
+func readCurrencyMappings() ([]exchange.CurrencyMapping, error) {
+	var mappings []exchange.CurrencyMapping
+	if err := viper.UnmarshalKey("currency_map", &mappings); err != nil {
+		return nil, fmt.Errorf("failed to read currency_map: %v", err)
+	}
+	return mappings, nil
+}
+
+// readOverrides reads the overrides section of the configuration.
+func readOverrides() ([]exchange.Override, error) {
+	var overrides []exchange.Override
+	if err := viper.UnmarshalKey("overrides", &overrides); err != nil {
+		return nil, fmt.Errorf("failed to read overrides: %v", err)
+	}
+	return overrides, nil
+}
+
+// readMarkups reads the markups section of the configuration.
+func readMarkups() ([]exchange.Markup, error) {
+	var markups []exchange.Markup
+	if err := viper.UnmarshalKey("markups", &markups); err != nil {
+		return nil, fmt.Errorf("failed to read markups: %v", err)
+	}
+	return markups, nil
+}
+
+// newFireflyApi creates a Firefly III client from the configuration.
+func newFireflyApi() (*firefly.Api, error) {
+	apiKey, err := resolveApiKey("firefly", viper.GetString("firefly.api_key"), viper.GetString("firefly.api_key_file"), viper.GetStringSlice("firefly.api_key_command"))
+	if err != nil {
+		return nil, err
+	}
+
+	apiUrl := viper.GetString("firefly.api_url")
+	if apiUrl == "" {
+		return nil, fmt.Errorf("firefly API URL is not set")
+	}
+
+	return firefly.NewApi(firefly.ApiConfig{
+		ApiKey:         apiKey,
+		ApiUrl:         apiUrl,
+		TimeoutSeconds: 10,
+		HTTPClient:     httpClient,
+	}), nil
+}
+
+// target is a named Firefly III instance from the targets section of the configuration.
+// The API key is read from one of ApiKey, ApiKeyFile or ApiKeyCommand.
+type target struct {
+	Name          string   `mapstructure:"name"`
+	ApiUrl        string   `mapstructure:"api_url"`
+	ApiKey        string   `mapstructure:"api_key"`
+	ApiKeyFile    string   `mapstructure:"api_key_file"`
+	ApiKeyCommand []string `mapstructure:"api_key_command"`
+}
+
+// readTargets reads the targets section of the configuration.
+func readTargets() ([]target, error) {
+	var targets []target
+	if err := viper.UnmarshalKey("targets", &targets); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %v", err)
+	}
+	return targets, nil
+}
+
+// newTargetApi creates a Firefly III client for the named target.
+// An empty name or "default" is the instance of the firefly section.
+func newTargetApi(name string) (*firefly.Api, error) {
+	if name == "" || name == "default" {
+		return newFireflyApi()
+	}
+
+	targets, err := readTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, t := range targets {
+		if t.Name != name {
+			names = append(names, t.Name)
+			continue
+		}
+		if t.ApiUrl == "" {
+			return nil, fmt.Errorf("API URL of target %q is not set", name)
+		}
+		apiKey, err := resolveApiKey(fmt.Sprintf("target %q", name), t.ApiKey, t.ApiKeyFile, t.ApiKeyCommand)
+		if err != nil {
+			return nil, err
+		}
+		return firefly.NewApi(firefly.ApiConfig{
+			ApiKey:         apiKey,
+			ApiUrl:         t.ApiUrl,
+			TimeoutSeconds: 10,
+			HTTPClient:     httpClient,
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown target %q, expected default or one of: %s", name, strings.Join(names, ", "))
+}
+
+// readNotifiers creates the notifiers of the notifiers section.
+func readNotifiers() ([]notify.Subscription, error) {
+	var configs []notify.Config
+	if err := viper.UnmarshalKey("notifiers", &configs); err != nil {
+		return nil, fmt.Errorf("failed to read notifiers: %v", err)
+	}
+
+	var subscriptions []notify.Subscription
+	for _, config := range configs {
+		addSecret(config.Password)
+		for _, value := range config.Headers {
+			addHeaderSecret(value)
+		}
+		config.HTTPClient = httpClient
+		s, err := notify.New(config)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+// readAnomalyConfig reads the anomaly section.
+func readAnomalyConfig() (notify.AnomalyConfig, error) {
+	var config notify.AnomalyConfig
+	if err := viper.UnmarshalKey("anomaly", &config); err != nil {
+		return config, fmt.Errorf("failed to read anomaly: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// journalDir returns the directory of the run journal, journal_dir or the default state directory.
+func journalDir() string {
+	if dir := viper.GetString("journal_dir"); dir != "" {
+		return dir
+	}
+	return journal.DefaultDir()
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+)
+
+// currenciesCmd represents the currencies command
+var currenciesCmd = &cobra.Command{
+	Use:   "currencies",
+	Short: "Inspect currencies supported by the provider and Firefly III",
+}
+
+var currenciesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List currencies supported by the provider",
+	Long: `List currency codes and names from the provider's currency catalogue. For example:
+
+    ffiii-rate-updater currencies list --search dollar`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		provider, err := newProvider()
+		if err != nil {
+			return err
+		}
+
+		catalogue, err := provider.Catalogue(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load currency catalogue: %v", err)
+		}
+
+		search, _ := cmd.Flags().GetString("search")
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tNAME")
+		for _, entry := range catalogue.Search(search) {
+			fmt.Fprintf(w, "%s\t%s\n", entry.Code, entry.Name)
+		}
+		return w.Flush()
+	},
+}
+
+var currenciesCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check configured currencies against the provider and Firefly III",
+	Long: `Validate the configured currencies against the provider's currency catalogue
+and the currencies enabled in Firefly III, and list codes that exist on only one side.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		currencies := viper.GetStringSlice("currencies")
+
+		mappings, err := readCurrencyMappings()
+		if err != nil {
+			return err
+		}
+
+		provider, err := newProvider()
+		if err != nil {
+			return err
+		}
+
+		mapping, err := exchange.NewCurrencyMap(mappings, provider.Name())
+		if err != nil {
+			return err
+		}
+
+		catalogue, err := provider.Catalogue(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load currency catalogue: %v", err)
+		}
+
+		fireflyApi, err := newFireflyApi()
+		if err != nil {
+			return err
+		}
+
+		fireflyCurrencies, err := fireflyApi.GetCurrencies(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get Firefly III currencies: %v", err)
+		}
+
+		enabled := make(map[string]bool)
+		for _, c := range fireflyCurrencies {
+			if c.Enabled {
+				enabled[c.Code] = true
+			}
+		}
+
+		if !checkCurrencies(cmd.OutOrStdout(), currencies, mapping, catalogue, provider.Name(), enabled) {
+			return fmt.Errorf("currency check failed")
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "All configured currencies are supported")
+		return nil
+	},
+}
+
+// checkCurrencies prints the configured currencies the provider does not support or that are
+// not enabled in Firefly III, and the enabled currencies that are not configured.
+//
+// Parameters:
+//   - w: where the problems are printed.
+//   - currencies: the configured Firefly III currency codes.
+//   - mapping: the currency mappings of the provider.
+//   - catalogue: the currencies supported by the provider.
+//   - provider: the provider name used in the output.
+//   - enabled: the codes of the currencies enabled in Firefly III.
+//
+// Returns:
+//   - Whether every configured currency is supported and enabled.
+func checkCurrencies(w io.Writer, currencies []string, mapping exchange.CurrencyMap, catalogue exchange.Catalogue, provider string, enabled map[string]bool) bool {
+
+	ok := true
+	configured := make(map[string]bool)
+	for _, code := range currencies {
+		currency := mapping.Currency(code)
+		configured[currency.String()] = true
+
+		if err := catalogue.Validate([]string{mapping.ProviderCode(currency)}); err != nil {
+			fmt.Fprintf(w, "%s: not supported by %s: %v\n", currency, provider, err)
+			ok = false
+		}
+		if !enabled[currency.String()] {
+			fmt.Fprintf(w, "%s: not enabled in Firefly III\n", currency)
+			ok = false
+		}
+	}
+
+	var unconfigured []string
+	for code := range enabled {
+		if !configured[code] {
+			unconfigured = append(unconfigured, code)
+		}
+	}
+	sort.Strings(unconfigured)
+	if len(unconfigured) > 0 {
+		fmt.Fprintf(w, "Enabled in Firefly III but not configured: %s\n", strings.Join(unconfigured, ", "))
+	}
+	return ok
+}
+
+func init() {
+	currenciesListCmd.Flags().StringP("search", "s", "", "Only show currencies whose code or name contains the text")
+
+	currenciesCmd.AddCommand(currenciesListCmd)
+	currenciesCmd.AddCommand(currenciesCheckCmd)
+	rootCmd.AddCommand(currenciesCmd)
+}
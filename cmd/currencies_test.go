@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+)
+
+func TestCheckCurrencies(t *testing.T) {
+
+	catalogue := exchange.Catalogue{"USD": "US Dollar", "EUR": "Euro", "GBP": "British Pound", "XAU": "Gold Ounce"}
+	mappings := []exchange.CurrencyMapping{{Firefly: "GOLD", Code: "xau", Multiplier: 31.1034768}}
+
+	tests := []struct {
+		name       string
+		currencies []string
+		enabled    []string
+		ok         bool
+		output     []string
+	}{
+		{
+			name:       "all supported",
+			currencies: []string{"USD", "EUR"},
+			enabled:    []string{"USD", "EUR"},
+			ok:         true,
+		},
+		{
+			name:       "case-insensitive",
+			currencies: []string{"usd", "Eur"},
+			enabled:    []string{"USD", "EUR"},
+			ok:         true,
+		},
+		{
+			name:       "mapped code",
+			currencies: []string{"USD", "GOLD"},
+			enabled:    []string{"USD", "GOLD"},
+			ok:         true,
+		},
+		{
+			name:       "unknown code with suggestion",
+			currencies: []string{"USD", "EURO"},
+			enabled:    []string{"USD", "EURO"},
+			output:     []string{`EURO: not supported by jsdelivr: unknown currencies: "EURO" (did you mean EUR?)`},
+		},
+		{
+			name:       "not enabled and not configured",
+			currencies: []string{"USD", "EUR"},
+			enabled:    []string{"USD", "GBP"},
+			output:     []string{"EUR: not enabled in Firefly III", "Enabled in Firefly III but not configured: GBP"},
+		},
+	}
+	for _, tt := range tests {
+		mapping, err := exchange.NewCurrencyMap(mappings, "jsdelivr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		enabled := make(map[string]bool)
+		for _, code := range tt.enabled {
+			enabled[code] = true
+		}
+
+		var out bytes.Buffer
+		ok := checkCurrencies(&out, tt.currencies, mapping, catalogue, "jsdelivr", enabled)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v\n%s", tt.name, ok, tt.ok, out.String())
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(tt.output) == 0 && out.Len() > 0 {
+			t.Errorf("%s: unexpected output %q", tt.name, out.String())
+			continue
+		}
+		for i, want := range tt.output {
+			if i >= len(lines) || lines[i] != want {
+				t.Errorf("%s: output %q, want line %q", tt.name, out.String(), want)
+			}
+		}
+	}
+}
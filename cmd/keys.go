@@ -0,0 +1,212 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// envPrefix is the prefix of all environment variables.
+const envPrefix = "FFIII_RATE_UPDATER"
+
+// Key types decide how an environment variable is parsed.
+const (
+	keyString   = "string"
+	keyInt      = "int"
+	keyFloat    = "float"
+	keyBool     = "bool"
+	keyDuration = "duration"
+	// keyList is a list of strings, comma-separated in the environment
+	keyList = "list"
+	// keySection is a list or map of objects, JSON in the environment
+	keySection = "section"
+)
+
+// Sources of a configuration value, as shown by config show --sources.
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// configKey is a key of the configuration.
+type configKey struct {
+	Key         string
+	Type        string
+	Default     any
+	Description string
+}
+
+// configKeys are all keys of the configuration file. Every key can also be set with an
+// environment variable, see envName. Add new settings here, flags of a command that are not
+// listed are still read from the environment, as strings.
+var configKeys = []configKey{
+	{"currencies", keyList, []string{}, "Currencies to fetch exchange rates for"},
+	{"date", keyString, "latest", "Date of the exchange rates (YYYY-MM-DD or latest)"},
+	{"provider", keyString, "jsdelivr", "Exchange rate provider"},
+	{"providers", keySection, nil, "Settings per provider"},
+	{"firefly.api_url", keyString, "", "Firefly III API URL"},
+	{"firefly.api_key", keyString, "", "Firefly III API key"},
+	{"firefly.api_key_file", keyString, "", "File holding the Firefly III API key"},
+	{"firefly.api_key_command", keyList, []string{}, "Command printing the Firefly III API key"},
+	{"currency_map", keySection, nil, "Mappings between Firefly III and provider currency codes"},
+	{"overrides", keySection, nil, "Manual rates and pegs"},
+	{"markups", keySection, nil, "Spreads or fees applied on top of the provider rates"},
+	{"concurrency.fetch", keyInt, defaultFetchConcurrency, "Maximum number of provider requests at the same time"},
+	{"concurrency.send", keyInt, defaultSendConcurrency, "Maximum number of Firefly III writes at the same time"},
+	{"rate_limits", keySection, nil, "Request limits per host"},
+	{"journal_dir", keyString, "", "Directory of the run journal"},
+	{"targets", keySection, nil, "Other Firefly III instances"},
+	{"prune.keep_days", keyInt, 90, "Number of days for which every daily rate is kept"},
+	{"prune.keep", keyString, "month-end", "Older rates to keep (month-end, week-end or none)"},
+	{"prune.keep_transaction_dates", keyBool, true, "Always keep rates on dates that have transactions"},
+	{"anomaly.threshold_percent", keyFloat, 10.0, "Smallest rate move that triggers the anomaly notification"},
+	{"anomaly.lookback_days", keyInt, 7, "How far back the previous rate is searched"},
+	{"notifiers", keySection, nil, "Notifications after update"},
+	{"aggregate", keyString, "", "Send period-average rates (weekly-average, monthly-average or yearly-average)"},
+	{"aggregate-method", keyString, "mean", "How daily rates are averaged (mean or median)"},
+	{"anchor", keyString, "first", "Day of the period the average rates are dated (first or last)"},
+	{"output", keyString, "text", "Output format of update (text or json)"},
+	{"report-file", keyString, "", "File the JSON run report of update is written to"},
+	{"log-level", keyString, "info", "Log level (debug, info, warn or error)"},
+	{"log-format", keyString, "text", "Log format (text or json)"},
+	{"quiet", keyBool, false, "Only log errors"},
+	{"timeout", keyDuration, "0s", "Deadline for the whole run"},
+}
+
+var (
+	// keySources records where each configuration key was set, filled by loadEnv.
+	keySources = map[string]string{}
+	// envConfig holds the configuration keys set in the environment, as nested maps.
+	envConfig = map[string]any{}
+)
+
+// envName returns the environment variable of a key, e.g. FFIII_RATE_UPDATER_FIREFLY_API_KEY
+// for firefly.api_key.
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// parseEnv converts the value of an environment variable to the type of the key.
+func parseEnv(k configKey, value string) (any, error) {
+	switch k.Type {
+	case keyInt:
+		return strconv.Atoi(strings.TrimSpace(value))
+	case keyFloat:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case keyBool:
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case keyDuration:
+		if _, err := time.ParseDuration(strings.TrimSpace(value)); err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace(value), nil
+	case keyList:
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	case keySection:
+		var section any
+		if err := json.Unmarshal([]byte(value), &section); err != nil {
+			return nil, fmt.Errorf("expected JSON: %v", err)
+		}
+		return section, nil
+	}
+	return value, nil
+}
+
+// loadEnv merges the environment variables of the configuration keys over the configuration
+// file and records the source of every key. Flags still win over the environment.
+// Flags of the command that are not configuration keys are bound to their variables too.
+func loadEnv(cmd *cobra.Command) error {
+
+	env := map[string]any{}
+	for _, k := range configKeys {
+		if viper.InConfig(k.Key) {
+			keySources[k.Key] = sourceFile
+		}
+
+		value, ok := os.LookupEnv(envName(k.Key))
+		if !ok {
+			continue
+		}
+		parsed, err := parseEnv(k, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", envName(k.Key), err)
+		}
+
+		// Nested keys become nested maps, so whole sections can still be read with UnmarshalKey
+		parts := strings.Split(k.Key, ".")
+		node := env
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = parsed
+		keySources[k.Key] = sourceEnv
+	}
+	if err := viper.MergeConfigMap(env); err != nil {
+		return fmt.Errorf("failed to read environment: %v", err)
+	}
+	envConfig = env
+
+	var bindErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if findKey(f.Name) == nil && bindErr == nil {
+			bindErr = viper.BindEnv(f.Name, envName(f.Name))
+		}
+	})
+	return bindErr
+}
+
+// findKey returns the configuration key with the name, or nil.
+func findKey(name string) *configKey {
+	for i := range configKeys {
+		if configKeys[i].Key == name {
+			return &configKeys[i]
+		}
+	}
+	return nil
+}
+
+// keySource returns where the effective value of the key comes from.
+func keySource(cmd *cobra.Command, key string) string {
+	if f := cmd.Flags().Lookup(key); f != nil && f.Changed {
+		return sourceFlag
+	}
+	if source, ok := keySources[key]; ok {
+		return source
+	}
+	return sourceDefault
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestEnvName(t *testing.T) {
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"currencies", "FFIII_RATE_UPDATER_CURRENCIES"},
+		{"firefly.api_key", "FFIII_RATE_UPDATER_FIREFLY_API_KEY"},
+		{"concurrency.fetch", "FFIII_RATE_UPDATER_CONCURRENCY_FETCH"},
+		{"aggregate-method", "FFIII_RATE_UPDATER_AGGREGATE_METHOD"},
+	}
+	for _, tt := range tests {
+		if got := envName(tt.key); got != tt.want {
+			t.Errorf("envName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+
+	tests := []struct {
+		keyType string
+		value   string
+		want    any
+		valid   bool
+	}{
+		{keyString, " latest ", " latest ", true},
+		{keyInt, " 4 ", 4, true},
+		{keyInt, "four", nil, false},
+		{keyFloat, "2.5", 2.5, true},
+		{keyBool, "true", true, true},
+		{keyBool, "yes", nil, false},
+		{keyDuration, "30s", "30s", true},
+		{keyDuration, "30", nil, false},
+		{keyList, "USD, EUR,,KGS ", []string{"USD", "EUR", "KGS"}, true},
+		{keyList, "", []string{}, true},
+		{keySection, `[{"from": "USD", "to": "KGS", "rate": 87.5}]`, []any{map[string]any{"from": "USD", "to": "KGS", "rate": 87.5}}, true},
+		{keySection, `{"ecb": {"timeout_seconds": 10}}`, map[string]any{"ecb": map[string]any{"timeout_seconds": float64(10)}}, true},
+		{keySection, "USD=KGS", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := parseEnv(configKey{Key: "test", Type: tt.keyType}, tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("parseEnv(%s, %q) error = %v, want valid %v", tt.keyType, tt.value, err, tt.valid)
+			continue
+		}
+		if tt.valid && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEnv(%s, %q) = %#v, want %#v", tt.keyType, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+
+	const file = `
+currencies: [USD]
+provider: ecb
+date: 2025-01-02
+firefly:
+  api_url: https://file.example.com/api/v1
+  api_key_file: /run/secrets/firefly
+concurrency:
+  fetch: 2
+`
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		flags  map[string]string
+		key    string
+		want   any
+		source string
+	}{
+		{"file", nil, nil, "provider", "ecb", sourceFile},
+		{"default", nil, nil, "output", nil, sourceDefault},
+		{"env over file", map[string]string{"FFIII_RATE_UPDATER_PROVIDER": "nbp"}, nil, "provider", "nbp", sourceEnv},
+		{"flag over env", map[string]string{"FFIII_RATE_UPDATER_PROVIDER": "nbp"}, map[string]string{"provider": "cbr"}, "provider", "cbr", sourceFlag},
+		{"comma list", map[string]string{"FFIII_RATE_UPDATER_CURRENCIES": "USD,EUR"}, nil, "currencies", []string{"USD", "EUR"}, sourceEnv},
+		{"nested key", map[string]string{"FFIII_RATE_UPDATER_FIREFLY_API_URL": "https://env.example.com/api/v1"}, nil, "firefly.api_url", "https://env.example.com/api/v1", sourceEnv},
+		{"nested sibling kept", map[string]string{"FFIII_RATE_UPDATER_FIREFLY_API_URL": "https://env.example.com/api/v1"}, nil, "firefly.api_key_file", "/run/secrets/firefly", sourceFile},
+		{"typed nested key", map[string]string{"FFIII_RATE_UPDATER_CONCURRENCY_FETCH": "8"}, nil, "concurrency.fetch", 8, sourceEnv},
+		{"JSON section", map[string]string{"FFIII_RATE_UPDATER_OVERRIDES": `[{"from": "USD", "to": "KGS", "rate": 87.5}]`}, nil, "overrides", []any{map[string]any{"from": "USD", "to": "KGS", "rate": 87.5}}, sourceEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			keySources = map[string]string{}
+			t.Cleanup(viper.Reset)
+
+			viper.SetConfigType("yaml")
+			if err := viper.ReadConfig(strings.NewReader(file)); err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().String("provider", "jsdelivr", "")
+			cmd.Flags().StringSlice("currencies", nil, "")
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := loadEnv(cmd); err != nil {
+				t.Fatal(err)
+			}
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := viper.Get(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.key, got, tt.want)
+			}
+			if got := keySource(cmd, tt.key); got != tt.source {
+				t.Errorf("source of %s = %s, want %s", tt.key, got, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	t.Setenv("FFIII_RATE_UPDATER_CONCURRENCY_SEND", "many")
+
+	err := loadEnv(&cobra.Command{Use: "test"})
+	if err == nil || !strings.Contains(err.Error(), "FFIII_RATE_UPDATER_CONCURRENCY_SEND") {
+		t.Errorf("loadEnv() = %v, want an error naming the variable", err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// setupLogging configures the default slog logger from --log-level, --log-format and --quiet.
+// Logs go to stderr, so they never mix with the data a command prints to stdout.
+func setupLogging() error {
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(viper.GetString("log-level"))); err != nil {
+		return fmt.Errorf("invalid log level %q, expected debug, info, warn or error", viper.GetString("log-level"))
+	}
+	if viper.GetBool("quiet") {
+		level = slog.LevelError
+	}
+
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	switch strings.ToLower(viper.GetString("log-format")) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid log format %q, expected text or json", viper.GetString("log-format"))
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// redactAttr removes the registered secrets from string and error attributes.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	switch v := a.Value.Any().(type) {
+	case string:
+		return slog.String(a.Key, redact(v))
+	case error:
+		return slog.String(a.Key, redact(v.Error()))
+	}
+	return a
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactAttr(t *testing.T) {
+
+	key, err := resolveApiKey("firefly", "", "", []string{"echo", "log-key-7265"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"string", slog.String("url", "https://firefly.example.com/?token="+key), "url=\"https://firefly.example.com/?token=" + redacted + "\""},
+		{"error", slog.Any("error", fmt.Errorf("unauthorized: %s", key)), "error=\"unauthorized: " + redacted + "\""},
+		{"number", slog.Int("status", 401), "status=401"},
+		{"no secret", slog.String("pair", "USD/EUR"), "pair=USD/EUR"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr}))
+		logger.Warn("Request failed", tt.attr)
+
+		line := buf.String()
+		if strings.Contains(line, key) {
+			t.Errorf("%s: record contains the key: %s", tt.name, line)
+		}
+		if !strings.Contains(line, tt.want) {
+			t.Errorf("%s: record %s, want %s", tt.name, line, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+)
+
+// mirrorCmd represents the mirror command
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Keep and serve a local mirror of the jsdelivr rate snapshots",
+	Long: `Sync rate snapshots of the fawazahmed0 currency API into a local directory and serve them
+over HTTP with the same URL layout ({date}/v1/currencies/{base}.min.json). Other instances can
+use the mirror with providers.jsdelivr.url or providers.jsdelivr.fallback_url. For example:
+
+    ffiii-rate-updater mirror sync --dir ./mirror --from 2025-01-01 --to 2025-01-31
+    ffiii-rate-updater mirror serve --dir ./mirror --listen :8080`,
+}
+
+var mirrorSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download rate snapshots into the mirror directory",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		currencies := viper.GetStringSlice("currencies")
+		if len(currencies) == 0 {
+			return fmt.Errorf("please provide the currencies to mirror")
+		}
+
+		dates, err := mirrorDates(cmd)
+		if err != nil {
+			return err
+		}
+
+		provider, err := newNamedProvider(exchange.DefaultProvider)
+		if err != nil {
+			return err
+		}
+		jsdelivr, ok := provider.(*exchange.JsDelivr)
+		if !ok {
+			return fmt.Errorf("mirror requires the %s provider", exchange.DefaultProvider)
+		}
+
+		mappings, err := readCurrencyMappings()
+		if err != nil {
+			return err
+		}
+		mapping, err := exchange.NewCurrencyMap(mappings, jsdelivr.Name())
+		if err != nil {
+			return err
+		}
+
+		var codes []string
+		for _, code := range currencies {
+			codes = append(codes, mapping.ProviderCode(mapping.Currency(code)))
+		}
+
+		dir, _ := cmd.Flags().GetString("dir")
+		written, err := jsdelivr.Mirror(cmd.Context(), dir, codes, dates)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Mirror sync complete", "files", written, "dir", dir)
+		return nil
+	},
+}
+
+var mirrorServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the mirror directory over HTTP",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		dir, _ := cmd.Flags().GetString("dir")
+		listen, _ := cmd.Flags().GetString("listen")
+
+		server := &http.Server{Addr: listen, Handler: exchange.MirrorHandler(dir)}
+		go func() {
+			<-cmd.Context().Done()
+			server.Shutdown(context.Background())
+		}()
+
+		slog.Info("Serving mirror", "dir", dir, "listen", listen)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+// mirrorDates returns the dates between --from and --to, or --date when no range is given.
+func mirrorDates(cmd *cobra.Command) ([]string, error) {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+
+	if from == "" && to == "" {
+		return []string{viper.GetString("date")}, nil
+	}
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("please provide both --from and --to")
+	}
+
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --from date: %v", err)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --to date: %v", err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("--to must not be before --from")
+	}
+
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
+}
+
+func init() {
+	mirrorCmd.PersistentFlags().String("dir", "./mirror", "Mirror directory")
+	mirrorSyncCmd.Flags().String("from", "", "First date to sync (format: YYYY-MM-DD)")
+	mirrorSyncCmd.Flags().String("to", "", "Last date to sync (format: YYYY-MM-DD)")
+	mirrorServeCmd.Flags().String("listen", ":8080", "Address to serve the mirror on")
+
+	mirrorCmd.AddCommand(mirrorSyncCmd)
+	mirrorCmd.AddCommand(mirrorServeCmd)
+	rootCmd.AddCommand(mirrorCmd)
+}
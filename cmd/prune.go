@@ -0,0 +1,126 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/prune"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old exchange rates from Firefly III according to a retention policy",
+	Long: `Remove old exchange rates from Firefly III. Every daily rate of the last --keep-days days
+is kept; older rates are thinned out to the last rate of each month (or week). Rates on
+dates with transactions are always kept unless --keep-transaction-dates=false is given. The
+policy is read from the prune section of the configuration, flags override it.
+
+prune only reports what it would remove unless --apply is given. For example:
+
+    ffiii-rate-updater prune --keep-days 90 --keep month-end
+    ffiii-rate-updater prune --keep-days 90 --keep month-end --apply`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		policy, err := readPrunePolicy(cmd)
+		if err != nil {
+			return err
+		}
+		apply, _ := cmd.Flags().GetBool("apply")
+
+		fireflyApi, err := newFireflyApi()
+		if err != nil {
+			return err
+		}
+
+		rates, err := fireflyApi.ListExchangeRates(cmd.Context(), firefly.ListOptions{})
+		if err != nil {
+			return err
+		}
+
+		today := time.Now()
+		var transactionDates map[string]bool
+		if policy.KeepTransactionDates {
+			transactionDates, err = fireflyApi.GetTransactionDates(cmd.Context(), "", policy.Cutoff(today))
+			if err != nil {
+				return err
+			}
+		}
+
+		removed := prune.Plan(rates, transactionDates, policy, today)
+		if len(removed) == 0 {
+			slog.Info("Nothing to prune", "rates", len(rates))
+			return nil
+		}
+
+		for _, rate := range removed {
+			fmt.Printf("%s %s/%s %.8f\n", rate.Date, rate.From, rate.To, rate.Rate)
+		}
+
+		if !apply {
+			slog.Info("Dry run, use --apply to delete the rates", "remove", len(removed), "rates", len(rates),
+				"from", removed[0].Date, "to", removed[len(removed)-1].Date)
+			return nil
+		}
+
+		deleted, err := prune.Apply(cmd.Context(), fireflyApi, removed)
+		if err != nil {
+			return fmt.Errorf("failed to prune after %d of %d rates: %v", deleted, len(removed), err)
+		}
+
+		slog.Info("Pruned exchange rates", "removed", deleted, "rates", len(rates), "from", removed[0].Date, "to", removed[len(removed)-1].Date)
+		return nil
+	},
+}
+
+// readPrunePolicy reads the prune section of the configuration, overridden by the command flags.
+func readPrunePolicy(cmd *cobra.Command) (prune.Policy, error) {
+	policy := prune.DefaultPolicy()
+	if err := viper.UnmarshalKey("prune", &policy); err != nil {
+		return policy, fmt.Errorf("failed to read prune policy: %v", err)
+	}
+	if cmd.Flags().Changed("keep-days") {
+		policy.KeepDays, _ = cmd.Flags().GetInt("keep-days")
+	}
+	if cmd.Flags().Changed("keep") {
+		policy.Keep, _ = cmd.Flags().GetString("keep")
+	}
+	if cmd.Flags().Changed("keep-transaction-dates") {
+		policy.KeepTransactionDates, _ = cmd.Flags().GetBool("keep-transaction-dates")
+	}
+
+	if err := policy.Validate(); err != nil {
+		return policy, fmt.Errorf("invalid prune policy: %v", err)
+	}
+	return policy, nil
+}
+
+func init() {
+	pruneCmd.Flags().Int("keep-days", 90, "Number of days for which every daily rate is kept")
+	pruneCmd.Flags().String("keep", prune.KeepMonthEnd, "Older rates to keep (month-end, week-end or none)")
+	pruneCmd.Flags().Bool("keep-transaction-dates", true, "Always keep rates on dates that have transactions (--keep-transaction-dates=false to remove them too)")
+	pruneCmd.Flags().Bool("apply", false, "Delete the rates instead of only reporting them")
+
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/prune"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func TestReadPrunePolicy(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		args   []string
+		want   prune.Policy
+	}{
+		{"defaults", nil, nil, prune.DefaultPolicy()},
+		{"partial section", map[string]any{"keep_days": 30}, nil, prune.Policy{KeepDays: 30, Keep: prune.KeepMonthEnd, KeepTransactionDates: true}},
+		{"opt-out in config", map[string]any{"keep_transaction_dates": false}, nil, prune.Policy{KeepDays: 90, Keep: prune.KeepMonthEnd}},
+		{"opt-out flag", nil, []string{"--keep-transaction-dates=false"}, prune.Policy{KeepDays: 90, Keep: prune.KeepMonthEnd}},
+		{"flag over config", map[string]any{"keep_days": 30, "keep_transaction_dates": false}, []string{"--keep-days", "7", "--keep-transaction-dates"}, prune.Policy{KeepDays: 7, Keep: prune.KeepMonthEnd, KeepTransactionDates: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(func() {
+				viper.Reset()
+				pruneCmd.Flags().VisitAll(func(f *pflag.Flag) {
+					f.Value.Set(f.DefValue)
+					f.Changed = false
+				})
+			})
+			if tt.config != nil {
+				viper.Set("prune", tt.config)
+			}
+			if err := pruneCmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			policy, err := readPrunePolicy(pruneCmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy != tt.want {
+				t.Errorf("policy = %+v, want %+v", policy, tt.want)
+			}
+		})
+	}
+}
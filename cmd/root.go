@@ -16,17 +16,25 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
 )
 
 var (
 	cfgFile string
+	// cancelRun releases the deadline of the run set by --timeout
+	cancelRun context.CancelFunc = func() {}
 )
 
 var rootCmd = &cobra.Command{
@@ -40,8 +48,34 @@ and updates them in Firefly III via its API. For example:
 or you can set currencies and date via flags:
 
     ffiii-rate-updater update --currencies USD,EUR,GBP --date 2025-01-01`,
+	// Errors are logged by Execute, so they follow --log-format
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return initializeConfig(cmd)
+		// Flags are parsed by now, later errors are not usage errors
+		cmd.SilenceUsage = true
+
+		if err := initializeConfig(cmd); err != nil {
+			return err
+		}
+		if !skipsChecks(cmd) {
+			if err := checkConfig(); err != nil {
+				return err
+			}
+			if err := validateSecrets(); err != nil {
+				return err
+			}
+		}
+		if err := applyRateLimits(); err != nil {
+			return err
+		}
+
+		// The deadline covers every request of the run, not each request alone
+		if timeout := viper.GetDuration("timeout"); timeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cmd.SetContext(ctx)
+			cancelRun = cancel
+		}
+		return nil
 	},
 }
 
@@ -75,28 +109,37 @@ var initConfigCmd = &cobra.Command{
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	// Ctrl-C and SIGTERM cancel the requests in flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	cancelRun()
+	stop()
 	if err != nil {
+		slog.Error("Command failed", "error", err)
 		os.Exit(1)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/ffiii-rate-updater/config)")
-	rootCmd.PersistentFlags().StringP("firefly.api_key", "k", "your_firefly_api_key_here", "Firefly III API key")
-	rootCmd.PersistentFlags().StringP("firefly.api_url", "u", "https://your-firefly-iii-instance.com/api/v1", "Firefly III API URL")
+	rootCmd.PersistentFlags().StringP("firefly.api_key", "k", "", "Firefly III API key (visible to other users in the process list, prefer api_key_file or api_key_command)")
+	rootCmd.PersistentFlags().StringP("firefly.api_url", "u", "", "Firefly III API URL (e.g. https://firefly.example.com/api/v1)")
 	rootCmd.PersistentFlags().StringSliceP("currencies", "c", []string{}, "List of currencies to fetch exchange rates for (e.g. USD,EUR,GBP)")
 	rootCmd.PersistentFlags().StringP("date", "d", "latest", "Date for which to fetch exchange rates (format: YYYY-MM-DD or 'latest')")
+	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn or error)")
+	rootCmd.PersistentFlags().String("log-format", "text", "Log format (text or json)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only log errors, e.g. for cron")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Deadline for the whole run, e.g. 10m (0 means no deadline)")
+	rootCmd.PersistentFlags().StringP("provider", "p", exchange.DefaultProvider, fmt.Sprintf("Exchange rate provider (%s)", strings.Join(exchange.ProviderNames(), ", ")))
 
 	rootCmd.AddCommand(initConfigCmd)
 }
 
 func initializeConfig(cmd *cobra.Command) error {
 
-	viper.SetEnvPrefix("FFIII_RATE_UPDATER")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "*", "-", "*"))
-	viper.AutomaticEnv()
-
+	if cfgFile == "" {
+		cfgFile = os.Getenv(envName("config"))
+	}
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -114,8 +157,11 @@ func initializeConfig(cmd *cobra.Command) error {
 		if !errors.As(err, &configFileNotFoundError) {
 			return err
 		}
-	} else {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	}
+
+	// The environment overrides the file, flags override both
+	if err := loadEnv(cmd); err != nil {
+		return err
 	}
 
 	err := viper.BindPFlags(cmd.Flags())
@@ -123,5 +169,12 @@ func initializeConfig(cmd *cobra.Command) error {
 		return err
 	}
 
+	if err := setupLogging(); err != nil {
+		return err
+	}
+	if viper.ConfigFileUsed() != "" {
+		slog.Debug("Using config file", "path", viper.ConfigFileUsed())
+	}
+
 	return nil
 }
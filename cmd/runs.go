@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ewok/ffiii-rate-updater/internal/journal"
+)
+
+// runsCmd represents the runs command
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "Inspect and revert past update runs",
+	Long: `Every update run records the exchange rates it writes, and the values they replaced,
+in a local journal. Use these commands to list past runs and to undo one of them.`,
+}
+
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List past update runs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		runs, err := journal.List(journalDir())
+		if err != nil {
+			return fmt.Errorf("failed to read run journal: %v", err)
+		}
+		if len(runs) == 0 {
+			fmt.Println("No runs recorded")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTARTED\tCOMMAND\tTARGET\tRATES\tREVERTED")
+		for _, run := range runs {
+			reverted := ""
+			if run.RevertedAt != nil {
+				reverted = run.RevertedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", run.ID, run.StartedAt.Local().Format(time.DateTime), run.Command, run.Target, len(run.Entries), reverted)
+		}
+		return w.Flush()
+	},
+}
+
+var runsRevertCmd = &cobra.Command{
+	Use:   "revert <id>",
+	Short: "Undo the exchange rates written by a run",
+	Long: `Undo an update run: rates the run created are deleted and rates it overwrote are set
+back to their previous values. For example:
+
+    ffiii-rate-updater runs revert 20251019-101500-a1b2c3
+
+A revert that fails partway records its progress; run it again to continue. The run is only
+reverted on the instance it wrote to, unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		dir := journalDir()
+		run, err := journal.Load(dir, args[0])
+		if err != nil {
+			return err
+		}
+
+		fireflyApi, err := newFireflyApi()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSuffix(fireflyApi.Config.ApiUrl, "/") != strings.TrimSuffix(run.Target, "/") {
+			force, _ := cmd.Flags().GetBool("force")
+			if !force {
+				return fmt.Errorf("run %s wrote to %s, not to %s; use --force to revert it there anyway", run.ID, run.Target, fireflyApi.Config.ApiUrl)
+			}
+			slog.Warn("Reverting on a different instance than the run wrote to", "run", run.ID, "target", run.Target, "url", fireflyApi.Config.ApiUrl)
+		}
+
+		// The progress is saved also when the revert fails, so running it again continues
+		reverted, err := journal.Revert(cmd.Context(), fireflyApi, run)
+		saveErr := journal.Save(dir, run)
+		if err != nil {
+			if saveErr != nil {
+				slog.Error("Failed to save run journal", "run", run.ID, "error", saveErr)
+			}
+			return fmt.Errorf("failed to revert run %s after %d of %d rates, run the command again to continue: %v", run.ID, run.RevertedEntries(), len(run.Entries), err)
+		}
+		if saveErr != nil {
+			return fmt.Errorf("failed to save run journal: %v", saveErr)
+		}
+
+		slog.Info("Reverted run", "run", run.ID, "rates", reverted)
+		return nil
+	},
+}
+
+func init() {
+	runsRevertCmd.Flags().Bool("force", false, "Revert on another Firefly III instance than the run wrote to")
+
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsRevertCmd)
+	rootCmd.AddCommand(runsCmd)
+}
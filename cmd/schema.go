@@ -0,0 +1,405 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+	"github.com/ewok/ffiii-rate-updater/internal/limit"
+	"github.com/ewok/ffiii-rate-updater/internal/notify"
+	"github.com/ewok/ffiii-rate-updater/internal/prune"
+	"github.com/ewok/ffiii-rate-updater/internal/textdist"
+)
+
+const (
+	datePattern     = `^\d{4}-\d{2}-\d{2}$`
+	apiUrlPattern   = `^https?://.+/api/v1$`
+	durationPattern = `^(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$|^0$`
+)
+
+// sectionTypes are the Go types the sections of the configuration are decoded into.
+var sectionTypes = map[string]reflect.Type{
+	"providers":    reflect.TypeOf(exchange.ProviderConfig{}),
+	"currency_map": reflect.TypeOf([]exchange.CurrencyMapping{}),
+	"overrides":    reflect.TypeOf([]exchange.Override{}),
+	"markups":      reflect.TypeOf([]exchange.Markup{}),
+	"rate_limits":  reflect.TypeOf([]limit.HostLimit{}),
+	"targets":      reflect.TypeOf([]target{}),
+	"notifiers":    reflect.TypeOf([]notify.Config{}),
+}
+
+// schemaExtras refine the schema of a key or section field, by path without list indexes
+// and provider names (e.g. notifiers.type or providers.holidays).
+func schemaExtras() map[string]map[string]any {
+	dateSchema := map[string]any{"type": "string", "format": "date", "pattern": datePattern}
+	apiUrlSchema := map[string]any{"type": "string", "format": "uri", "pattern": apiUrlPattern, "description": "Firefly III API URL, ending with /api/v1"}
+	return map[string]map[string]any{
+		"date":                    {"anyOf": []any{map[string]any{"const": "latest"}, dateSchema}},
+		"provider":                {"enum": exchange.ProviderNames()},
+		"firefly.api_url":         apiUrlSchema,
+		"prune.keep":              {"enum": []string{prune.KeepMonthEnd, prune.KeepWeekEnd, prune.KeepNone}},
+		"aggregate":               {"enum": []string{"", exchange.AggregateWeekly, exchange.AggregateMonthly, exchange.AggregateYearly}},
+		"aggregate-method":        {"enum": []string{exchange.MethodMean, exchange.MethodMedian}},
+		"anchor":                  {"enum": []string{exchange.AnchorFirst, exchange.AnchorLast}},
+		"output":                  {"enum": []string{"text", "json"}},
+		"log-level":               {"enum": []string{"debug", "info", "warn", "error"}},
+		"log-format":              {"enum": []string{"text", "json"}},
+		"timeout":                 {"pattern": durationPattern},
+		"providers.url":           {"format": "uri"},
+		"providers.fallback_url":  {"format": "uri"},
+		"providers.time_of_day":   {"enum": []string{exchange.TimeOfDayClose, exchange.TimeOfDayOpen}},
+		"providers.holidays":      {"items": dateSchema},
+		"overrides.start":         dateSchema,
+		"overrides.end":           dateSchema,
+		"markups.side":            {"enum": []string{exchange.SideBuy, exchange.SideSell}},
+		"targets.api_url":         apiUrlSchema,
+		"notifiers.type":          {"enum": notify.Types()},
+		"notifiers.on":            {"items": map[string]any{"type": "string", "enum": notify.Triggers()}},
+		"notifiers.url":           {"format": "uri"},
+		"currencies":              {"uniqueItems": true},
+		"firefly.api_key_command": {"minItems": 1},
+	}
+}
+
+// configSchema returns the JSON Schema of the configuration file.
+func configSchema() map[string]any {
+
+	extras := schemaExtras()
+	root := objectSchema()
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "ffiii-rate-updater configuration"
+
+	for _, k := range configKeys {
+		var schema map[string]any
+		switch k.Type {
+		case keyInt:
+			schema = map[string]any{"type": "integer"}
+		case keyFloat:
+			schema = map[string]any{"type": "number"}
+		case keyBool:
+			schema = map[string]any{"type": "boolean"}
+		case keyList:
+			schema = map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+		case keySection:
+			if k.Key == "providers" {
+				// One object per provider, each with the settings of ProviderConfig
+				schema = objectSchema()
+				for _, name := range exchange.ProviderNames() {
+					schema["properties"].(map[string]any)[name] = typeSchema(sectionTypes[k.Key], k.Key, extras)
+				}
+			} else {
+				schema = typeSchema(sectionTypes[k.Key], k.Key, extras)
+			}
+		default:
+			schema = map[string]any{"type": "string"}
+		}
+		for name, value := range extras[k.Key] {
+			schema[name] = value
+		}
+		schema["description"] = k.Description
+		if k.Default != nil && k.Type != keySection {
+			schema["default"] = k.Default
+		}
+
+		// Nested keys live in objects of their own, e.g. firefly.api_url
+		node := root
+		parts := strings.Split(k.Key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			properties := node["properties"].(map[string]any)
+			child, ok := properties[part].(map[string]any)
+			if !ok {
+				child = objectSchema()
+				properties[part] = child
+			}
+			node = child
+		}
+		node["properties"].(map[string]any)[parts[len(parts)-1]] = schema
+	}
+	return root
+}
+
+// objectSchema returns the schema of an object without unknown properties.
+func objectSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{},
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the schema of a Go type decoded from the configuration, following
+// the mapstructure tags of its fields.
+func typeSchema(t reflect.Type, path string, extras map[string]map[string]any) map[string]any {
+
+	var schema map[string]any
+	switch t.Kind() {
+	case reflect.Struct:
+		schema = objectSchema()
+		properties := schema["properties"].(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := field.Tag.Get("mapstructure")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			properties[name] = typeSchema(field.Type, path+"."+name, extras)
+		}
+	case reflect.Slice:
+		// Fields of listed objects keep the path of the list, e.g. notifiers.type
+		elemPath := path + "[]"
+		if t.Elem().Kind() == reflect.Struct {
+			elemPath = path
+		}
+		schema = map[string]any{"type": "array", "items": typeSchema(t.Elem(), elemPath, extras)}
+	case reflect.Map:
+		schema = map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), path+".*", extras)}
+	case reflect.String:
+		schema = map[string]any{"type": "string"}
+	case reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		schema = map[string]any{"type": "integer"}
+	case reflect.Float64:
+		schema = map[string]any{"type": "number"}
+	default:
+		schema = map[string]any{}
+	}
+
+	// Lists of objects share the path of their elements, the extras are the elements'
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		for name, value := range extras[path] {
+			schema[name] = value
+		}
+	}
+	return schema
+}
+
+// validateConfig checks the configuration file and the environment against the schema.
+//
+// Returns:
+//   - The problems found, one per line, e.g. `unknown key "curencies"`; empty when valid.
+func validateConfig() ([]string, error) {
+
+	v := viper.New()
+	if file := viper.ConfigFileUsed(); file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.MergeConfigMap(envConfig); err != nil {
+		return nil, err
+	}
+
+	problems := validateValue(v.AllSettings(), configSchema(), "")
+
+	// Currency codes are case-insensitive, so uniqueItems alone misses USD and usd
+	seen := map[string]bool{}
+	for _, c := range v.GetStringSlice("currencies") {
+		code := strings.ToUpper(strings.TrimSpace(c))
+		if seen[code] {
+			problems = append(problems, fmt.Sprintf("currencies: duplicate currency %q", c))
+		}
+		seen[code] = true
+	}
+
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// validateValue checks a value against the subset of JSON Schema produced by configSchema.
+func validateValue(value any, schema map[string]any, path string) []string {
+
+	name := path
+	if name == "" {
+		name = "configuration"
+	}
+
+	// YAML decodes unquoted dates as timestamps
+	if t, ok := value.(time.Time); ok {
+		value = t.Format("2006-01-02")
+	}
+
+	if options, ok := schema["anyOf"].([]any); ok {
+		for _, option := range options {
+			if len(validateValue(value, option.(map[string]any), path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: invalid value %v", name, value)}
+	}
+	if c, ok := schema["const"]; ok && value != c {
+		return []string{fmt.Sprintf("%s: expected %v", name, c)}
+	}
+
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a map, got %s", name, describe(value))}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		var keys []string
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinPath(path, key)
+			if s, ok := properties[key].(map[string]any); ok {
+				problems = append(problems, validateValue(object[key], s, child)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]any:
+				problems = append(problems, validateValue(object[key], additional, child)...)
+			case bool:
+				if !additional {
+					problems = append(problems, fmt.Sprintf("%s: unknown key%s", child, suggest(key, properties)))
+				}
+			}
+		}
+		return problems
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			if texts, ok := value.([]string); ok {
+				for _, text := range texts {
+					list = append(list, text)
+				}
+			} else {
+				return []string{fmt.Sprintf("%s: expected a list, got %s", name, describe(value))}
+			}
+		}
+		if minItems, ok := schema["minItems"].(int); ok && len(list) < minItems {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %d items", name, minItems))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range list {
+				problems = append(problems, validateValue(item, items, fmt.Sprintf("%s[%d]", name, i))...)
+			}
+		}
+		return problems
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []string{fmt.Sprintf("%s: expected text, got %s", name, describe(value))}
+		}
+	case "integer":
+		switch n := value.(type) {
+		case int, int64, uint64:
+		case float64:
+			if n != math.Trunc(n) {
+				return []string{fmt.Sprintf("%s: expected a whole number, got %v", name, n)}
+			}
+		default:
+			return []string{fmt.Sprintf("%s: expected a whole number, got %s", name, describe(value))}
+		}
+	case "number":
+		switch value.(type) {
+		case int, int64, uint64, float64:
+		default:
+			return []string{fmt.Sprintf("%s: expected a number, got %s", name, describe(value))}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: expected true or false, got %s", name, describe(value))}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]string); ok {
+		found := false
+		for _, e := range enum {
+			found = found || value == e
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: invalid value %q, expected one of: %s", name, value, strings.Join(enum, ", "))}
+		}
+	}
+
+	s, _ := value.(string)
+	if pattern, ok := schema["pattern"].(string); ok && s != "" && !regexp.MustCompile(pattern).MatchString(s) {
+		if pattern == apiUrlPattern {
+			return []string{fmt.Sprintf("%s: %q must be the API URL ending with /api/v1, e.g. https://firefly.example.com/api/v1", name, s)}
+		}
+		return []string{fmt.Sprintf("%s: invalid value %q", name, s)}
+	}
+	switch schema["format"] {
+	case "date":
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return []string{fmt.Sprintf("%s: invalid date %q", name, s)}
+		}
+	case "uri":
+		if u, err := url.Parse(s); s != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+			return []string{fmt.Sprintf("%s: invalid URL %q", name, s)}
+		}
+	}
+	return nil
+}
+
+// joinPath appends a key to a path of the configuration.
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// describe names the type of a configuration value for error messages.
+func describe(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nothing"
+	case string:
+		return fmt.Sprintf("text %q", value)
+	case bool:
+		return "a boolean"
+	case int, int64, uint64, float64:
+		return fmt.Sprintf("the number %v", value)
+	case []any, []string:
+		return "a list"
+	case map[string]any:
+		return "a map"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// suggest returns a hint with the known key closest to a misspelled one.
+func suggest(key string, properties map[string]any) string {
+	best, bestDistance := "", 3
+	for known := range properties {
+		if d := textdist.Levenshtein(key, known); d < bestDistance || (d == bestDistance && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateConfig(t *testing.T) {
+
+	const valid = `
+currencies: [USD, EUR, KGS]
+date: 2025-01-02
+provider: ecb
+firefly:
+  api_url: https://firefly.example.com/api/v1
+  api_key_file: /run/secrets/firefly
+currency_map:
+  - firefly: GOLD
+    code: xau
+    multiplier: 31.1034768
+overrides:
+  - from: USD
+    to: KGS
+    rate: 87.5
+    start: 2025-01-01
+markups:
+  - to: KGS
+    percent: 1.5
+    side: sell
+concurrency:
+  fetch: 8
+notifiers:
+  - type: webhook
+    url: https://ntfy.example.com/rates
+    on: [failure, anomaly]
+timeout: 10m
+`
+
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]any
+		want   []string
+	}{
+		{"valid", valid, nil, nil},
+		{"latest", "date: latest\n", nil, nil},
+		{"misspelled key", "curencies: [USD, EUR]\n", nil, []string{`curencies: unknown key, did you mean "currencies"?`}},
+		{"unknown nested key", "firefly:\n  api_urll: https://firefly.example.com/api/v1\n", nil, []string{`firefly.api_urll: unknown key, did you mean "api_url"?`}},
+		{"wrong type", "concurrency:\n  fetch: four\n", nil, []string{`concurrency.fetch: expected a whole number, got text "four"`}},
+		{"fraction", "concurrency:\n  fetch: 2.5\n", nil, []string{"concurrency.fetch: expected a whole number, got 2.5"}},
+		{"list expected", "currencies: USD\n", nil, []string{`currencies: expected a list, got text "USD"`}},
+		{"API URL", "firefly:\n  api_url: https://firefly.example.com\n", nil, []string{"must be the API URL ending with /api/v1"}},
+		{"invalid date", "date: 2025-13-01\n", nil, []string{"date: invalid value 2025-13-01"}},
+		{"override date", "overrides:\n  - from: USD\n    to: KGS\n    rate: 87.5\n    start: \"2025-1-1\"\n", nil, []string{`overrides[0].start: invalid value "2025-1-1"`}},
+		{"impossible date", "overrides:\n  - from: USD\n    to: KGS\n    rate: 87.5\n    end: 2025-02-30\n", nil, []string{`overrides[0].end: invalid date "2025-02-30"`}},
+		{"enum", "provider: yahoo\n", nil, []string{`provider: invalid value "yahoo", expected one of:`}},
+		{"nested enum", "markups:\n  - to: KGS\n    side: both\n", nil, []string{`markups[0].side: invalid value "both"`}},
+		{"trigger", "notifiers:\n  - type: webhook\n    url: https://ntfy.example.com\n    on: [never]\n", nil, []string{`notifiers[0].on[0]: invalid value "never"`}},
+		{"URL", "notifiers:\n  - type: webhook\n    url: ntfy\n    on: [failure]\n", nil, []string{`notifiers[0].url: invalid URL "ntfy"`}},
+		{"duplicate currency", "currencies: [USD, EUR, usd]\n", nil, []string{`currencies: duplicate currency "usd"`}},
+		{"empty key command", "firefly:\n  api_key_command: []\n", nil, []string{"firefly.api_key_command: expected at least 1 items"}},
+		{"environment", "provider: ecb\n", map[string]any{"prune": map[string]any{"keep": "daily"}}, []string{`prune.keep: invalid value "daily"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			envConfig = tt.env
+			t.Cleanup(func() { envConfig = map[string]any{} })
+
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			viper.SetConfigFile(file)
+			if err := viper.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := validateConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("problems = %q, want %d", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("problem %q, want it to contain %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// apiKeyCommandTimeout limits api_key_command, e.g. a password manager waiting for a PIN.
+const apiKeyCommandTimeout = 30 * time.Second
+
+// redacted replaces secrets in logs, errors and reports.
+const redacted = "[REDACTED]"
+
+// placeholders are values of the examples and of earlier flag defaults that are never valid.
+var placeholders = []string{
+	"your_firefly_api_key_here",
+	"your_api_key",
+	"your_api_key_here",
+	"your_staging_api_key_here",
+	"your-firefly-iii-instance.com",
+	"your-firefly-instance.com",
+	"changeme",
+}
+
+var (
+	// secrets are the resolved secrets of the run, redacted by redact
+	secrets   []string
+	secretsMu sync.Mutex
+)
+
+// addSecret registers a secret so it is redacted from logs, errors and reports.
+// Very short values are ignored, redacting them would mangle unrelated text.
+func addSecret(secret string) {
+	if len(secret) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// addHeaderSecret registers the value of an HTTP header, and the credentials of a value with
+// an authentication scheme (e.g. "Bearer <token>"), which may appear without the scheme.
+// Templates of notifier headers are rendered per event and are not registered.
+func addHeaderSecret(value string) {
+	if strings.Contains(value, "{{") {
+		return
+	}
+	addSecret(value)
+	if _, credentials, ok := strings.Cut(strings.TrimSpace(value), " "); ok {
+		addSecret(strings.TrimSpace(credentials))
+	}
+}
+
+// redact replaces every registered secret in s.
+func redact(s string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// isPlaceholder returns true if the value is, or contains, a placeholder from the examples.
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	for _, p := range placeholders {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveApiKey returns the API key from exactly one of a literal key, a file or a command.
+//
+// Parameters:
+//   - name: the name of the instance used in errors (e.g. "firefly" or a target name).
+//   - key: the literal key, api_key.
+//   - file: the file holding the key, api_key_file, e.g. a Docker or Kubernetes secret.
+//   - command: the command printing the key, api_key_command, e.g. pass or a vault CLI.
+//
+// Returns:
+//   - The key, trimmed of surrounding whitespace, and an error if none or more than one source
+//     is set, the source fails, or the key is a placeholder.
+func resolveApiKey(name string, key string, file string, command []string) (string, error) {
+
+	sources := 0
+	for _, set := range []bool{key != "", file != "", len(command) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources == 0 {
+		return "", fmt.Errorf("API key of %s is not set, use api_key, api_key_file or api_key_command", name)
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("API key of %s is set more than once, use only one of api_key, api_key_file or api_key_command", name)
+	}
+
+	switch {
+	case file != "":
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key of %s: %v", name, err)
+		}
+		key = string(raw)
+	case len(command) > 0:
+		ctx, cancel := context.WithTimeout(context.Background(), apiKeyCommandTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			// stdout may hold part of the key, only stderr is reported
+			return "", fmt.Errorf("API key command of %s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+		}
+		key = stdout.String()
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("API key of %s is empty", name)
+	}
+	addSecret(key)
+	if isPlaceholder(key) {
+		return "", fmt.Errorf("API key of %s is a placeholder, set your Firefly III personal access token", name)
+	}
+	return key, nil
+}
+
+// validateSecrets rejects placeholder values of the Firefly III settings, so a forgotten
+// setting fails at startup instead of sending a bogus token.
+func validateSecrets() error {
+
+	if key := viper.GetString("firefly.api_key"); key != "" {
+		addSecret(key)
+		if isPlaceholder(key) {
+			return fmt.Errorf("firefly.api_key is a placeholder, set your Firefly III personal access token")
+		}
+	}
+	if url := viper.GetString("firefly.api_url"); isPlaceholder(url) {
+		return fmt.Errorf("firefly.api_url %q is a placeholder, set the API URL of your Firefly III instance", url)
+	}
+
+	targets, err := readTargets()
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		if t.ApiKey != "" {
+			addSecret(t.ApiKey)
+			if isPlaceholder(t.ApiKey) {
+				return fmt.Errorf("API key of target %q is a placeholder", t.Name)
+			}
+		}
+		if isPlaceholder(t.ApiUrl) {
+			return fmt.Errorf("API URL %q of target %q is a placeholder", t.ApiUrl, t.Name)
+		}
+	}
+	return nil
+}
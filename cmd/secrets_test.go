@@ -0,0 +1,234 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/updater"
+)
+
+func TestResolveApiKey(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "key")
+	if err := os.WriteFile(file, []byte("  file-key-2041\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	placeholder := filepath.Join(dir, "placeholder")
+	if err := os.WriteFile(placeholder, []byte("your_firefly_api_key_here\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		file    string
+		command []string
+		want    string
+		err     string
+	}{
+		{name: "literal key", key: "literal-key-1187", want: "literal-key-1187"},
+		{name: "file trimmed", file: file, want: "file-key-2041"},
+		{name: "command", command: []string{"sh", "-c", "echo ' command-key-3390 '"}, want: "command-key-3390"},
+		{name: "no source", err: "is not set"},
+		{name: "key and file", key: "literal-key-1187", file: file, err: "more than once"},
+		{name: "file and command", file: file, command: []string{"true"}, err: "more than once"},
+		{name: "all sources", key: "literal-key-1187", file: file, command: []string{"true"}, err: "more than once"},
+		{name: "missing file", file: filepath.Join(dir, "missing"), err: "failed to read API key"},
+		{name: "empty command output", command: []string{"true"}, err: "is empty"},
+		{name: "placeholder key", key: "your_api_key_here", err: "placeholder"},
+		{name: "placeholder in file", file: placeholder, err: "placeholder"},
+	}
+	for _, tt := range tests {
+		key, err := resolveApiKey("firefly", tt.key, tt.file, tt.command)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || key != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, key, err, tt.want)
+		}
+	}
+}
+
+func TestResolveApiKeyCommandFailure(t *testing.T) {
+
+	_, err := resolveApiKey("staging", "", "", []string{"sh", "-c", "echo partial-key-6650; echo vault is sealed >&2; exit 3"})
+	if err == nil {
+		t.Fatal("expected the failing command to fail")
+	}
+	if !strings.Contains(err.Error(), "vault is sealed") || !strings.Contains(err.Error(), "staging") {
+		t.Errorf("error %q, want the name and stderr", err)
+	}
+	if strings.Contains(err.Error(), "partial-key-6650") {
+		t.Errorf("error %q contains stdout", err)
+	}
+}
+
+func TestIsPlaceholder(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"your_firefly_api_key_here", true},
+		{"  YOUR_API_KEY_HERE\n", true},
+		{"your_api_key", true},
+		{"https://your-firefly-instance.com/api/v1", true},
+		{"https://your-firefly-iii-instance.com/api/v1", true},
+		{"changeme", true},
+		{"eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9", false},
+		{"https://firefly.example.com/api/v1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isPlaceholder(tt.value); got != tt.want {
+			t.Errorf("isPlaceholder(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+
+	addSecret("redact-secret-8812")
+	addSecret("abc")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"key redact-secret-8812 rejected", "key " + redacted + " rejected"},
+		{"redact-secret-8812redact-secret-8812", redacted + redacted},
+		// Values shorter than four characters are not registered
+		{"abc", "abc"},
+		{"no secret here", "no secret here"},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.text); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSecrets(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		err      string
+	}{
+		{name: "valid", settings: map[string]any{"firefly.api_key": "valid-key-5521", "firefly.api_url": "https://firefly.example.com/api/v1"}},
+		{name: "placeholder key", settings: map[string]any{"firefly.api_key": "your_firefly_api_key_here"}, err: "firefly.api_key is a placeholder"},
+		{name: "placeholder URL", settings: map[string]any{"firefly.api_url": "https://your-firefly-instance.com/api/v1"}, err: "firefly.api_url"},
+		{
+			name:     "placeholder target key",
+			settings: map[string]any{"targets": []map[string]any{{"name": "staging", "api_url": "https://staging.example.com/api/v1", "api_key": "your_staging_api_key_here"}}},
+			err:      `target "staging" is a placeholder`,
+		},
+		{
+			name:     "placeholder target URL",
+			settings: map[string]any{"targets": []map[string]any{{"name": "staging", "api_url": "https://your-firefly-iii-instance.com/api/v1", "api_key": "valid-key-5521"}}},
+			err:      `of target "staging"`,
+		},
+	}
+	for _, tt := range tests {
+		viper.Reset()
+		for key, value := range tt.settings {
+			viper.Set(key, value)
+		}
+		err := validateSecrets()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+	viper.Reset()
+
+	// Literal keys are registered even when they are rejected
+	if redact("your_firefly_api_key_here") != redacted {
+		t.Error("the placeholder key was not registered as a secret")
+	}
+}
+
+func TestRedactReport(t *testing.T) {
+
+	key, err := resolveApiKey("firefly", "report-key-9043", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := redactReport(updater.Report{
+		Error:   "request with " + key + " failed",
+		Batches: []updater.Batch{{From: "USD", Error: "status 401 for " + key}},
+	})
+	if strings.Contains(report.Error, key) || strings.Contains(report.Batches[0].Error, key) {
+		t.Errorf("report still contains the key: %+v", report)
+	}
+	if report.Error != "request with "+redacted+" failed" {
+		t.Errorf("error %q", report.Error)
+	}
+}
+
+func TestReadNotifiersSecrets(t *testing.T) {
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("notifiers", []map[string]any{
+		{"type": "smtp", "host": "mail.example.com", "from": "rates@example.com", "to": []string{"me@example.com"}, "password": "smtp-pass-4821", "on": []string{"failure"}},
+		{"type": "webhook", "url": "https://hooks.example.com", "on": []string{"failure"}, "headers": map[string]string{
+			"Authorization": "Bearer hook-token-7731",
+			"X-Api-Key":     "hook-key-5519",
+			"Title":         "{{.Title}}",
+		}},
+	})
+
+	if _, err := readNotifiers(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"auth failed for smtp-pass-4821", "auth failed for " + redacted},
+		{"sent Bearer hook-token-7731", "sent " + redacted},
+		{"token hook-token-7731 rejected", "token " + redacted + " rejected"},
+		{"key hook-key-5519", "key " + redacted},
+		// Header templates are rendered per event, they are no secret
+		{"title {{.Title}}", "title {{.Title}}"},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.text); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if strings.Contains(redact("mail.example.com"), redacted) {
+		t.Error("the SMTP host was registered as a secret")
+	}
+}
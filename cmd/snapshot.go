@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/snapshot"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [file]",
+	Short: "Dump all exchange rates of Firefly III to a file",
+	Long: `Dump every exchange rate stored in Firefly III to a versioned JSON or CSV file,
+depending on the file extension. For example:
+
+    ffiii-rate-updater snapshot rates-before-backfill.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		path := fmt.Sprintf("ffiii-rates-%s.json", time.Now().Format("20060102-150405"))
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		fireflyApi, err := newFireflyApi()
+		if err != nil {
+			return err
+		}
+
+		s, err := snapshot.Take(cmd.Context(), fireflyApi, firefly.ListOptions{})
+		if err != nil {
+			return err
+		}
+
+		if err := snapshot.Write(path, s); err != nil {
+			return fmt.Errorf("failed to write snapshot: %v", err)
+		}
+
+		slog.Info("Saved snapshot", "rates", len(s.Rates), "path", path)
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Write a snapshot of exchange rates back to Firefly III",
+	Long: `Write the exchange rates of a snapshot back to Firefly III. Rates that already exist
+are handled according to --conflict: overwrite, skip-existing or fail. For example:
+
+    ffiii-rate-updater restore rates-before-backfill.json --conflict overwrite`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		s, err := snapshot.Read(args[0])
+		if err != nil {
+			return err
+		}
+
+		fireflyApi, err := newFireflyApi()
+		if err != nil {
+			return err
+		}
+
+		conflict, _ := cmd.Flags().GetString("conflict")
+		result, err := snapshot.Restore(cmd.Context(), fireflyApi, s, conflict)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Restore complete", "created", result.Created, "updated", result.Updated, "skipped", result.Skipped)
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().String("conflict", snapshot.ConflictFail, "What to do with existing rates (overwrite, skip-existing or fail)")
+
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
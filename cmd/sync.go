@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/snapshot"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Replicate exchange rates from one Firefly III instance to another",
+	Long: `Read the exchange rates of one Firefly III instance, optionally limited to a date range,
+and write them to another. Only rates that are missing or differ on the destination are
+written. Targets are defined in the targets section of the configuration; "default" is the
+instance of the firefly section. For example:
+
+    ffiii-rate-updater sync --from-target default --to-target staging --from 2025-01-01`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		fromTarget, _ := cmd.Flags().GetString("from-target")
+		toTarget, _ := cmd.Flags().GetString("to-target")
+		if fromTarget == toTarget {
+			return fmt.Errorf("source and destination target are both %q", fromTarget)
+		}
+		options, err := syncRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		source, err := newTargetApi(fromTarget)
+		if err != nil {
+			return err
+		}
+		destination, err := newTargetApi(toTarget)
+		if err != nil {
+			return err
+		}
+		if source.Config.ApiUrl == destination.Config.ApiUrl {
+			return fmt.Errorf("targets %q and %q point to the same instance %s", fromTarget, toTarget, source.Config.ApiUrl)
+		}
+
+		s, err := snapshot.Take(cmd.Context(), source, options)
+		if err != nil {
+			return err
+		}
+		slog.Info("Read exchange rates", "rates", len(s.Rates), "url", source.Config.ApiUrl)
+
+		result, err := snapshot.Restore(cmd.Context(), destination, s, snapshot.ConflictOverwrite)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Sync complete", "created", result.Created, "updated", result.Updated, "unchanged", result.Skipped)
+		return nil
+	},
+}
+
+// syncRange returns the date range of --from and --to. Either side may be left open.
+func syncRange(cmd *cobra.Command) (firefly.ListOptions, error) {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+
+	if from != "" {
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			return firefly.ListOptions{}, fmt.Errorf("invalid --from date: %v", err)
+		}
+	}
+	if to != "" {
+		if _, err := time.Parse("2006-01-02", to); err != nil {
+			return firefly.ListOptions{}, fmt.Errorf("invalid --to date: %v", err)
+		}
+	}
+	if from != "" && to != "" && to < from {
+		return firefly.ListOptions{}, fmt.Errorf("--to must not be before --from")
+	}
+	return firefly.ListOptions{Start: from, End: to}, nil
+}
+
+func init() {
+	syncCmd.Flags().String("from-target", "default", "Target to read exchange rates from")
+	syncCmd.Flags().String("to-target", "", "Target to write exchange rates to")
+	syncCmd.Flags().String("from", "", "First date to sync (format: YYYY-MM-DD)")
+	syncCmd.Flags().String("to", "", "Last date to sync (format: YYYY-MM-DD)")
+	syncCmd.MarkFlagRequired("to-target")
+
+	rootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+func TestSyncRange(t *testing.T) {
+
+	tests := []struct {
+		from  string
+		to    string
+		want  firefly.ListOptions
+		valid bool
+	}{
+		{"", "", firefly.ListOptions{}, true},
+		{"2025-01-01", "", firefly.ListOptions{Start: "2025-01-01"}, true},
+		{"2025-01-01", "2025-01-31", firefly.ListOptions{Start: "2025-01-01", End: "2025-01-31"}, true},
+		{"2025-1-1", "", firefly.ListOptions{}, false},
+		{"", "2025-02-30", firefly.ListOptions{}, false},
+		{"2025-02-01", "2025-01-31", firefly.ListOptions{}, false},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "sync"}
+		cmd.Flags().String("from", tt.from, "")
+		cmd.Flags().String("to", tt.to, "")
+
+		got, err := syncRange(cmd)
+		if (err == nil) != tt.valid {
+			t.Errorf("syncRange(%q, %q) error = %v, want valid %v", tt.from, tt.to, err, tt.valid)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("syncRange(%q, %q) = %+v, want %+v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
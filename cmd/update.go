@@ -16,14 +16,20 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"ffiii-rate-updater/internal/exchange"
-	"ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/notify"
+	"github.com/ewok/ffiii-rate-updater/updater"
 )
 
 // updateCmd represents the update command
@@ -33,67 +39,250 @@ var updateCmd = &cobra.Command{
 	Long:  `Fetch exchange rates for specified currencies and update them in Firefly III.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		currencies := viper.GetStringSlice("currencies")
+		// Notifiers come first, so every later error reaches them
+		subscriptions, err := readNotifiers()
+		if err != nil {
+			return err
+		}
 
-		if len(currencies) < 2 {
-			return fmt.Errorf("please provide at least two currencies to fetch exchange rates")
+		report, err := runUpdate(cmd)
+		if len(subscriptions) > 0 {
+			notifyRun(cmd.Context(), report.fireflyApi, report.Report, subscriptions, report.anomaly)
 		}
+		return err
+	},
+}
+
+// updateRun is the outcome of update, with what notifyRun needs besides the report.
+type updateRun struct {
+	updater.Report
+	fireflyApi *firefly.Api
+	anomaly    notify.AnomalyConfig
+}
+
+// runUpdate fetches and sends the rates. Errors before the run starts are reported as a
+// failed run without batches.
+func runUpdate(cmd *cobra.Command) (updateRun, error) {
+
+	run := updateRun{}
+	fail := func(err error) (updateRun, error) {
+		run.Report.Error = redact(err.Error())
+		return run, err
+	}
+
+	currencies := viper.GetStringSlice("currencies")
+
+	if len(currencies) < 2 {
+		return fail(fmt.Errorf("please provide at least two currencies to fetch exchange rates"))
+	}
+
+	fireflyApi, err := newFireflyApi()
+	if err != nil {
+		return fail(err)
+	}
 
-		apiKey := viper.GetString("firefly.api_key")
-		if apiKey == "" {
-			return fmt.Errorf("firefly API key is not set")
+	mappings, err := readCurrencyMappings()
+	if err != nil {
+		return fail(err)
+	}
+
+	overrides, err := readOverrides()
+	if err != nil {
+		return fail(err)
+	}
+
+	markups, err := readMarkups()
+	if err != nil {
+		return fail(err)
+	}
+
+	name := viper.GetString("provider")
+	providerConfig, err := readProviderConfig(name)
+	if err != nil {
+		return fail(err)
+	}
+	provider, err := updater.NewProvider(name, updater.ProviderConfig(providerConfig))
+	if err != nil {
+		return fail(err)
+	}
+
+	fetchConcurrency, err := readConcurrency(cmd, "fetch")
+	if err != nil {
+		return fail(err)
+	}
+	sendConcurrency, err := readConcurrency(cmd, "send")
+	if err != nil {
+		return fail(err)
+	}
+
+	var aggregate *updater.AggregateConfig
+	if period := viper.GetString("aggregate"); period != "" {
+		aggregate = &updater.AggregateConfig{
+			Period: period,
+			Method: viper.GetString("aggregate-method"),
+			Anchor: viper.GetString("anchor"),
 		}
+	}
+
+	anomalyConfig, err := readAnomalyConfig()
+	if err != nil {
+		return fail(err)
+	}
 
-		apiUrl := viper.GetString("firefly.api_url")
-		if apiUrl == "" {
-			return fmt.Errorf("firefly API URL is not set")
+	output := viper.GetString("output")
+	if output != "text" && output != "json" {
+		return fail(fmt.Errorf("unknown output %q, expected text or json", output))
+	}
+
+	report, err := updater.Run(cmd.Context(), updater.Options{
+		Client:           updater.NewClient(updater.ClientConfig(fireflyApi.Config)),
+		Provider:         provider,
+		Currencies:       currencies,
+		Date:             viper.GetString("date"),
+		Mappings:         convertAll(mappings, func(m exchange.CurrencyMapping) updater.CurrencyMapping { return updater.CurrencyMapping(m) }),
+		Overrides:        convertAll(overrides, func(o exchange.Override) updater.Override { return updater.Override(o) }),
+		Markups:          convertAll(markups, func(m exchange.Markup) updater.Markup { return updater.Markup(m) }),
+		Aggregate:        aggregate,
+		FetchConcurrency: fetchConcurrency,
+		SendConcurrency:  sendConcurrency,
+		JournalDir:       journalDir(),
+	})
+	run = updateRun{Report: redactReport(report), fireflyApi: fireflyApi, anomaly: anomalyConfig}
+
+	if reportErr := writeReport(cmd, run.Report, output, viper.GetString("report-file")); reportErr != nil {
+		if err != nil {
+			slog.Error("Failed to write report", "error", reportErr)
+			return run, err
 		}
+		return run, reportErr
+	}
+	return run, err
+}
+
+// convertAll converts the sections read from the configuration to the types of the updater package.
+func convertAll[T any, U any](values []T, convert func(T) U) []U {
+	result := make([]U, len(values))
+	for i, v := range values {
+		result[i] = convert(v)
+	}
+	return result
+}
+
+// notifyFailure sends the failure event of an update that did not start, e.g. because of an
+// invalid configuration. Notifiers that cannot be read are logged and skipped.
+func notifyFailure(cmd *cobra.Command, err error) {
+
+	subscriptions, readErr := readNotifiers()
+	if readErr != nil {
+		slog.Warn("Failed to read notifiers, the failure is not notified", "error", readErr)
+		return
+	}
+	if len(subscriptions) > 0 {
+		notifyRun(cmd.Context(), nil, updater.Report{Error: redact(err.Error())}, subscriptions, notify.AnomalyConfig{})
+	}
+}
+
+// notifyRun sends the events of the run to the notifiers. Notifications are sent also when
+// the run was cancelled, with a deadline of their own. Anomalies are only detected with a Firefly III client.
+func notifyRun(ctx context.Context, fireflyApi *firefly.Api, report updater.Report, subscriptions []notify.Subscription, anomalyConfig notify.AnomalyConfig) {
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+	defer cancel()
 
-		exchangeApi, err := exchange.NewApi(currencies, viper.GetString("date"))
+	run := notifyRunOf(report)
+	var anomalies []notify.Anomaly
+	for _, s := range subscriptions {
+		if fireflyApi == nil || !s.Wants(notify.TriggerAnomaly) {
+			continue
+		}
+		var err error
+		anomalies, err = notify.DetectAnomalies(ctx, fireflyApi, run, anomalyConfig)
 		if err != nil {
-			return fmt.Errorf("failed to initialize exchange API: %v", err)
+			slog.Warn("Failed to detect anomalies", "error", err)
 		}
+		break
+	}
+
+	notify.Dispatch(ctx, subscriptions, notify.Events(run, anomalies))
+}
 
-		fireflyApi := firefly.NewApi(firefly.ApiConfig{
-			ApiKey:         apiKey,
-			ApiUrl:         apiUrl,
-			TimeoutSeconds: 10,
-		})
-
-		// Send exchange rates as batch
-		for i := range currencies {
-			fromCurrency := currencies[i]
-			rates := make(map[string]float64)
-			date := ""
-
-			for j := range currencies {
-				if i != j {
-					toCurrency := currencies[j]
-					rate, err := exchangeApi.GetRate(fromCurrency, toCurrency)
-					if err != nil {
-						log.Printf("Error fetching rate for %s/%s: %v", fromCurrency, toCurrency, err)
-						continue
-					}
-					rates[toCurrency] = rate.Value
-					// if not set yet, set the date
-					if date == "" {
-						date = rate.Date
-					}
-				}
-			}
-
-			err = fireflyApi.SendExchangeRateByDate(fromCurrency, rates, date)
-			if err != nil {
-				log.Printf("Error sending batch rates for %s: %v", fromCurrency, err)
-				break
-			}
-			log.Printf("Sent batch exchange rates for %s on %s", fromCurrency, date)
+// notifyRunOf converts the report into what the notifications need, keeping the report
+// itself for the templates.
+func notifyRunOf(report updater.Report) notify.Run {
+	run := notify.Run{
+		Provider: report.Provider,
+		Dates:    report.Dates,
+		Sent:     report.Totals.Sent,
+		Failed:   report.Totals.Failed,
+		Skipped:  report.Totals.Skipped,
+		Error:    report.Error,
+		Report:   report,
+	}
+	for _, b := range report.Batches {
+		batch := notify.Batch{From: b.From, Date: b.Date, Sent: b.Status == updater.BatchSent, Rates: b.Rates, Error: b.Error}
+		for _, s := range b.Skipped {
+			batch.Skipped = append(batch.Skipped, notify.SkippedRate{To: s.To, Reason: s.Reason})
 		}
+		run.Batches = append(run.Batches, batch)
+	}
+	return run
+}
+
+// redactReport removes the registered secrets from the errors of the report.
+func redactReport(report updater.Report) updater.Report {
+	report.Error = redact(report.Error)
+	batches := make([]updater.Batch, len(report.Batches))
+	for i, b := range report.Batches {
+		b.Error = redact(b.Error)
+		batches[i] = b
+	}
+	report.Batches = batches
+	return report
+}
+
+// writeReport prints the report as JSON when output is json and writes it to file when set.
+func writeReport(cmd *cobra.Command, report updater.Report, output string, file string) error {
 
+	if output != "json" && file == "" {
 		return nil
-	},
+	}
+
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+	body = append(body, '\n')
+
+	if output == "json" {
+		if _, err := cmd.OutOrStdout().Write(body); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	}
+	if file != "" {
+		if err := os.WriteFile(file, body, 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	}
+	return nil
 }
 
 func init() {
+	updateCmd.Flags().String("aggregate", "", "Send period-average rates instead of daily rates (weekly-average, monthly-average or yearly-average)")
+	updateCmd.Flags().String("aggregate-method", exchange.MethodMean, "How daily rates are averaged (mean or median)")
+	updateCmd.Flags().String("anchor", exchange.AnchorFirst, "Day of the period the average rates are dated (first or last)")
+	updateCmd.Flags().Int("fetch-concurrency", defaultFetchConcurrency, "Maximum number of provider requests at the same time")
+	updateCmd.Flags().Int("send-concurrency", defaultSendConcurrency, "Maximum number of Firefly III writes at the same time")
+	updateCmd.Flags().StringP("output", "o", "text", "Output format, text (logs only) or json (run report on stdout)")
+	updateCmd.Flags().String("report-file", "", "Write the JSON run report to this file")
+
+	// Errors of the checks before the run, e.g. an invalid configuration, are notified too
+	updateCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		err := rootCmd.PersistentPreRunE(cmd, args)
+		if err != nil {
+			notifyFailure(cmd, err)
+		}
+		return err
+	}
+
 	rootCmd.AddCommand(updateCmd)
 }
@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/ewok/ffiii-rate-updater/internal/notify"
+)
+
+func TestUpdateNotifiesFailures(t *testing.T) {
+
+	var (
+		mu     sync.Mutex
+		events []notify.Event
+	)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "placeholder API key",
+			config: "currencies: [USD, EUR]\nfirefly:\n  api_url: http://127.0.0.1:1/api/v1\n  api_key: your_api_key_here\n",
+			want:   "placeholder",
+		},
+		{
+			name:   "failing API key command",
+			config: "currencies: [USD, EUR]\nfirefly:\n  api_url: http://127.0.0.1:1/api/v1\n  api_key_command: [\"false\"]\n",
+			want:   "API key command",
+		},
+		{
+			name:   "bad currency map",
+			config: "currencies: [USD, EUR]\nfirefly:\n  api_url: http://127.0.0.1:1/api/v1\n  api_key: test-key-123\ncurrency_map:\n  - firefly: USD\n",
+			want:   "currency mapping",
+		},
+		{
+			name:   "one currency",
+			config: "currencies: [USD]\nfirefly:\n  api_url: http://127.0.0.1:1/api/v1\n  api_key: test-key-123\n",
+			want:   "at least two currencies",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			mu.Lock()
+			events = nil
+			mu.Unlock()
+
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			config := tt.config + "notifiers:\n  - type: webhook\n    url: " + hook.URL + "\n    on: [failure]\n"
+			if err := os.WriteFile(file, []byte(config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			rootCmd.SetArgs([]string{"update", "--config", file, "--quiet"})
+			err := rootCmd.ExecuteContext(context.Background())
+			if err == nil {
+				t.Fatal("expected update to fail")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) != 1 || events[0].Trigger != notify.TriggerFailure {
+				t.Fatalf("events = %+v, want one failure", events)
+			}
+			if !strings.Contains(events[0].Message, tt.want) || events[0].Message != redact(err.Error()) {
+				t.Errorf("message %q, want the error %q mentioning %q", events[0].Message, err, tt.want)
+			}
+		})
+	}
+}
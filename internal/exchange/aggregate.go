@@ -0,0 +1,265 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/limit"
+)
+
+const (
+	AggregateWeekly  = "weekly-average"
+	AggregateMonthly = "monthly-average"
+	AggregateYearly  = "yearly-average"
+
+	MethodMean   = "mean"
+	MethodMedian = "median"
+
+	AnchorFirst = "first"
+	AnchorLast  = "last"
+)
+
+// AggregateConfig describes how daily rates are combined into a period rate.
+type AggregateConfig struct {
+	// Period is one of AggregateWeekly, AggregateMonthly or AggregateYearly.
+	Period string
+	// Method is MethodMean or MethodMedian.
+	Method string
+	// Anchor is AnchorFirst or AnchorLast, the day of the period the result is dated.
+	Anchor string
+}
+
+// Validate checks that the aggregation settings are known.
+func (c AggregateConfig) Validate() error {
+	switch c.Period {
+	case AggregateWeekly, AggregateMonthly, AggregateYearly:
+	default:
+		return fmt.Errorf("unknown aggregate %q, expected %s, %s or %s", c.Period, AggregateWeekly, AggregateMonthly, AggregateYearly)
+	}
+	switch c.Method {
+	case MethodMean, MethodMedian:
+	default:
+		return fmt.Errorf("unknown aggregate method %q, expected %s or %s", c.Method, MethodMean, MethodMedian)
+	}
+	switch c.Anchor {
+	case AnchorFirst, AnchorLast:
+	default:
+		return fmt.Errorf("unknown anchor %q, expected %s or %s", c.Anchor, AnchorFirst, AnchorLast)
+	}
+	return nil
+}
+
+// Coverage describes which days of a period an average is based on.
+type Coverage struct {
+	// Start and End are the first and last day of the period (YYYY-MM-DD).
+	Start string
+	End   string
+	// Days are the days with rates, the average is based on them.
+	Days []string
+	// Unpublished are the days the provider answered with the rates of an earlier day,
+	// e.g. weekends and holidays of a central bank. They are not averaged.
+	Unpublished []string
+	// Missing are the past days the provider had no rates for.
+	Missing []string
+	// Pending are the days of the period after today, not available yet.
+	Pending []string
+}
+
+// Complete reports whether every day of the period has rates or is a day without publication.
+func (c Coverage) Complete() bool {
+	return len(c.Missing) == 0 && len(c.Pending) == 0
+}
+
+// PeriodBounds returns the first and last day of the period containing the date.
+// Weeks start on Monday.
+func (c AggregateConfig) PeriodBounds(date time.Time) (time.Time, time.Time) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch c.Period {
+	case AggregateWeekly:
+		offset := (int(date.Weekday()) + 6) % 7
+		start := date.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	case AggregateYearly:
+		start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	default:
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+}
+
+// NewAggregateApi creates a new Api instance whose rates are the average of the daily rates
+// of the period containing the date. Days in the future, days the provider has no data for
+// and days it answers with the rates of an earlier day (weekends, holidays) are skipped and
+// listed in Api.Coverage, so each published rate is averaged once; Rate.Days counts the daily
+// rates of each average.
+// The rates are dated on the anchor day of the period.
+//
+// Each pair is averaged in one direction, from the alphabetically first currency, and the
+// other direction is its reciprocal: the mean of 1/x is not 1/mean(x), so averaging both
+// directions would send an inconsistent pair.
+//
+// Parameters:
+//   - provider: the source of the exchange rates.
+//   - rawCurrencies: a slice of currency codes (e.g., "USD", "EUR") for which to fetch exchange rates.
+//   - date: any date within the period (e.g., "2024-06-01"), or "latest" for the current period.
+//   - mappings: the configured currency code mappings between Firefly III and the providers.
+//   - overrides: the configured manual rates and pegs, applied to every daily snapshot.
+//   - config: the aggregation settings.
+//   - concurrency: the maximum number of days fetched at the same time.
+//
+// Returns:
+//
+//	A pointer to an Api struct initialized with the aggregated exchange rates.
+func NewAggregateApi(ctx context.Context, provider Provider, rawCurrencies []string, date string, mappings []CurrencyMapping, overrides []Override, config AggregateConfig, concurrency int) (*Api, error) {
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC()
+	day := today
+	if date != "" && date != "latest" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %v", date, err)
+		}
+		day = parsed
+	}
+
+	start, end := config.PeriodBounds(day)
+	anchor := start
+	if config.Anchor == AnchorLast {
+		anchor = end
+	}
+
+	coverage := Coverage{Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")}
+	var days []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.After(today) {
+			coverage.Pending = append(coverage.Pending, d.Format("2006-01-02"))
+			continue
+		}
+		days = append(days, d.Format("2006-01-02"))
+	}
+
+	// Days are fetched concurrently, each day's currencies one after another
+	apis := make([]*Api, len(days))
+	errs := make([]error, len(days))
+	err := limit.Run(ctx, concurrency, len(days), func(ctx context.Context, i int) error {
+		apis[i], errs[i] = NewApi(ctx, provider, rawCurrencies, days[i], mappings, overrides, 1)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var daily []*Api
+	var lastErr error
+	for i, day := range days {
+		if errs[i] != nil {
+			slog.Warn("Skipping day without rates", "provider", provider.Name(), "date", day, "error", errs[i])
+			coverage.Missing = append(coverage.Missing, day)
+			lastErr = errs[i]
+			continue
+		}
+		// Providers answer weekends and holidays with the previous rates, which are already
+		// in the period or belong to the previous one; counting them again would weight them
+		if published := apis[i].Date; published != "" && published != day {
+			slog.Debug("Skipping day without publication", "provider", provider.Name(), "date", day, "published", published)
+			coverage.Unpublished = append(coverage.Unpublished, day)
+			continue
+		}
+		coverage.Days = append(coverage.Days, day)
+		daily = append(daily, apis[i])
+	}
+
+	if len(daily) == 0 {
+		return nil, fmt.Errorf("no rates available between %s and %s: %v", coverage.Start, coverage.End, lastErr)
+	}
+
+	slog.Info("Aggregating daily rates", "provider", provider.Name(), "days", len(daily), "unpublished", len(coverage.Unpublished), "start", coverage.Start, "end", coverage.End)
+	if !coverage.Complete() {
+		slog.Warn("Period average is incomplete", "start", coverage.Start, "end", coverage.End, "days", len(coverage.Days), "missing", len(coverage.Missing), "pending", len(coverage.Pending))
+	}
+
+	api := Api{
+		Provider:   provider,
+		Mapping:    daily[0].Mapping,
+		Currencies: daily[0].Currencies,
+		Date:       anchor.Format("2006-01-02"),
+		Coverage:   &coverage,
+	}
+
+	for i, a := range api.Currencies {
+		for _, b := range api.Currencies[i+1:] {
+			if a == b {
+				continue
+			}
+			from, to := a, b
+			if to.GetCode() < from.GetCode() {
+				from, to = to, from
+			}
+
+			var values []float64
+			override := false
+			for _, d := range daily {
+				value, ok := d.crossRate(from, to)
+				if !ok || value == 0 {
+					continue
+				}
+				values = append(values, value)
+				if rate, err := d.GetRate(from.String(), to.String()); err == nil {
+					override = override || rate.Override
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			value := aggregate(values, config.Method)
+			api.Rates = append(api.Rates,
+				Rate{Date: api.Date, Pair: Pair{From: from, To: to}, Value: value, Override: override, Days: len(values)},
+				Rate{Date: api.Date, Pair: Pair{From: to, To: from}, Value: 1 / value, Override: override, Days: len(values)},
+			)
+		}
+	}
+
+	return &api, nil
+}
+
+func aggregate(values []float64, method string) float64 {
+	if method == MethodMedian {
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		mid := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[mid-1] + sorted[mid]) / 2
+		}
+		return sorted[mid]
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
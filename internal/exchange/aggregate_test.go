@@ -0,0 +1,255 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dailyProvider serves the USD/EUR rate of each date, and fails for dates without one.
+type dailyProvider map[string]float64
+
+func (p dailyProvider) Name() string { return "daily" }
+
+func (p dailyProvider) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+	rate, ok := p[date]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("no rates on %s", date)
+	}
+	if strings.EqualFold(currency, "usd") {
+		return ApiResponse{Date: date, Rates: map[string]float64{"eur": rate}}, nil
+	}
+	return ApiResponse{Date: date, Rates: map[string]float64{"usd": 1 / rate}}, nil
+}
+
+func (p dailyProvider) Catalogue(ctx context.Context) (Catalogue, error) {
+	return Catalogue{"USD": "usd", "EUR": "eur"}, nil
+}
+
+func TestAggregate(t *testing.T) {
+
+	tests := []struct {
+		method string
+		values []float64
+		want   float64
+	}{
+		{MethodMean, []float64{1, 2, 3, 6}, 3},
+		{MethodMedian, []float64{6, 1, 3}, 3},
+		{MethodMedian, []float64{6, 1, 2, 3}, 2.5},
+		{MethodMean, []float64{0.9}, 0.9},
+	}
+	for _, tt := range tests {
+		if got := aggregate(tt.values, tt.method); got != tt.want {
+			t.Errorf("%s of %v = %v, want %v", tt.method, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+
+	tests := []struct {
+		period string
+		date   string
+		start  string
+		end    string
+	}{
+		{AggregateWeekly, "2025-01-15", "2025-01-13", "2025-01-19"},
+		{AggregateWeekly, "2025-01-19", "2025-01-13", "2025-01-19"},
+		{AggregateMonthly, "2024-02-10", "2024-02-01", "2024-02-29"},
+		{AggregateYearly, "2025-06-30", "2025-01-01", "2025-12-31"},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		start, end := AggregateConfig{Period: tt.period}.PeriodBounds(date)
+		if start.Format("2006-01-02") != tt.start || end.Format("2006-01-02") != tt.end {
+			t.Errorf("%s of %s = %s..%s, want %s..%s", tt.period, tt.date, start.Format("2006-01-02"), end.Format("2006-01-02"), tt.start, tt.end)
+		}
+	}
+}
+
+func TestNewAggregateApi(t *testing.T) {
+
+	// The week of 2025-01-13 without data on the weekend
+	provider := dailyProvider{
+		"2025-01-13": 0.90,
+		"2025-01-14": 0.95,
+		"2025-01-15": 1.00,
+		"2025-01-16": 0.85,
+		"2025-01-17": 0.80,
+	}
+
+	tests := []struct {
+		method     string
+		currencies []string
+	}{
+		{MethodMean, []string{"USD", "EUR"}},
+		{MethodMean, []string{"EUR", "USD"}},
+		{MethodMedian, []string{"USD", "EUR"}},
+	}
+	for _, tt := range tests {
+		config := AggregateConfig{Period: AggregateWeekly, Method: tt.method, Anchor: AnchorLast}
+		api, err := NewAggregateApi(context.Background(), provider, tt.currencies, "2025-01-15", nil, nil, config, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if api.Date != "2025-01-19" {
+			t.Errorf("%s: date %s, want the anchor 2025-01-19", tt.method, api.Date)
+		}
+
+		// EUR comes first alphabetically: EUR/USD is averaged, USD/EUR is its reciprocal
+		eurUsd, err := api.GetRate("EUR", "USD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		usdEur, err := api.GetRate("USD", "EUR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantEurUsd := aggregate([]float64{1 / 0.90, 1 / 0.95, 1 / 1.00, 1 / 0.85, 1 / 0.80}, tt.method)
+		if math.Abs(eurUsd.Value-wantEurUsd) > 1e-12 {
+			t.Errorf("%s %v: EUR/USD = %v, want %v", tt.method, tt.currencies, eurUsd.Value, wantEurUsd)
+		}
+		if math.Abs(eurUsd.Value*usdEur.Value-1) > 1e-12 {
+			t.Errorf("%s %v: EUR/USD %v and USD/EUR %v are not reciprocal", tt.method, tt.currencies, eurUsd.Value, usdEur.Value)
+		}
+		if eurUsd.Days != 5 || usdEur.Days != 5 {
+			t.Errorf("%s: days %d and %d, want 5", tt.method, eurUsd.Days, usdEur.Days)
+		}
+
+		c := api.Coverage
+		if c == nil {
+			t.Fatal("missing coverage")
+		}
+		if c.Start != "2025-01-13" || c.End != "2025-01-19" || len(c.Days) != 5 || len(c.Pending) != 0 || c.Complete() {
+			t.Errorf("%s: coverage %+v", tt.method, *c)
+		}
+		if strings.Join(c.Missing, ",") != "2025-01-18,2025-01-19" {
+			t.Errorf("%s: missing %v, want the weekend", tt.method, c.Missing)
+		}
+	}
+}
+
+// closedProvider serves the USD/EUR rate of each date, and the rate of the closest earlier
+// date on days without one, like a central bank on weekends and holidays.
+type closedProvider struct {
+	dailyProvider
+}
+
+func (p closedProvider) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	for i := 0; i < 7; i++ {
+		published := day.AddDate(0, 0, -i).Format("2006-01-02")
+		if _, ok := p.dailyProvider[published]; ok {
+			return p.dailyProvider.FetchRates(ctx, currency, published)
+		}
+	}
+	return ApiResponse{}, fmt.Errorf("no rates on or before %s", date)
+}
+
+func TestNewAggregateApiUnpublished(t *testing.T) {
+
+	// The week of 2025-01-13, the weekend answers with Friday's rate; Friday is the outlier
+	provider := closedProvider{dailyProvider{
+		"2025-01-10": 0.70,
+		"2025-01-13": 0.90,
+		"2025-01-14": 0.95,
+		"2025-01-15": 1.00,
+		"2025-01-16": 0.85,
+		"2025-01-17": 0.50,
+	}}
+
+	tests := []struct {
+		period string
+		date   string
+		values []float64
+		days   int
+		closed string
+	}{
+		{AggregateWeekly, "2025-01-15", []float64{0.90, 0.95, 1.00, 0.85, 0.50}, 5, "2025-01-18,2025-01-19"},
+		// Sunday the 12th falls back to Friday the 10th, outside the week
+		{AggregateWeekly, "2025-01-08", []float64{0.70}, 1, "2025-01-11,2025-01-12"},
+	}
+	for _, tt := range tests {
+		config := AggregateConfig{Period: tt.period, Method: MethodMean, Anchor: AnchorFirst}
+		api, err := NewAggregateApi(context.Background(), provider, []string{"USD", "EUR"}, tt.date, nil, nil, config, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rate, err := api.GetRate("USD", "EUR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 1 / aggregate(invert(tt.values), MethodMean)
+		if math.Abs(rate.Value-want) > 1e-12 || rate.Days != tt.days {
+			t.Errorf("%s: USD/EUR = %v over %d days, want %v over %d", tt.date, rate.Value, rate.Days, want, tt.days)
+		}
+
+		c := api.Coverage
+		if strings.Join(c.Unpublished, ",") != tt.closed || len(c.Days) != tt.days {
+			t.Errorf("%s: days %v, unpublished %v; want %d days and %s", tt.date, c.Days, c.Unpublished, tt.days, tt.closed)
+		}
+	}
+
+	// A week without missing days is complete, the weekend is no gap
+	api, err := NewAggregateApi(context.Background(), provider, []string{"USD", "EUR"}, "2025-01-15", nil, nil, AggregateConfig{Period: AggregateWeekly, Method: MethodMean, Anchor: AnchorFirst}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !api.Coverage.Complete() || len(api.Coverage.Missing) != 0 {
+		t.Errorf("coverage %+v, want complete", *api.Coverage)
+	}
+}
+
+// invert returns the reciprocals of the values.
+func invert(values []float64) []float64 {
+	inverted := make([]float64, len(values))
+	for i, v := range values {
+		inverted[i] = 1 / v
+	}
+	return inverted
+}
+
+func TestNewAggregateApiPending(t *testing.T) {
+
+	today := time.Now().UTC()
+	provider := dailyProvider{today.Format("2006-01-02"): 0.9}
+
+	config := AggregateConfig{Period: AggregateYearly, Method: MethodMean, Anchor: AnchorFirst}
+	api, err := NewAggregateApi(context.Background(), provider, []string{"USD", "EUR"}, "latest", nil, nil, config, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := api.Coverage
+	end := time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+	wantPending := int(end.Sub(time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	wantMissing := today.YearDay() - 1
+	if len(c.Days) != 1 || len(c.Missing) != wantMissing || len(c.Pending) != wantPending {
+		t.Errorf("coverage: %d days, %d missing, %d pending; want 1, %d and %d", len(c.Days), len(c.Missing), len(c.Pending), wantMissing, wantPending)
+	}
+	if c.Complete() != (wantMissing == 0 && wantPending == 0) {
+		t.Errorf("coverage: complete %v", c.Complete())
+	}
+}
@@ -15,26 +15,61 @@ limitations under the License.
 */
 package exchange
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
 
 type ApiConfig struct {
-	URL            string
-	FallbackURL    string
+	Name         string
+	URL          string
+	FallbackURL  string
+	CatalogueURL string
+	// CacheDir is the directory for cached provider data. Empty disables caching.
+	CacheDir       string
 	TimeoutSeconds int
+	// HTTPClient is the client used for all requests, shared to reuse connections.
+	// When nil, http.DefaultClient is used.
+	HTTPClient *http.Client
 }
 
 func GetApiConfig() ApiConfig {
 	return ApiConfig{
-		URL: "https://cdn.jsdelivr.net/npm/@fawazahmed0/currency-api@%s/v1/%s/%s.min.json",
-		// FallbackURL: "https://%s.currency-api.pages.dev/v1/%s/%s.min.json",
+		Name:           "jsdelivr",
+		URL:            "https://cdn.jsdelivr.net/npm/@fawazahmed0/currency-api@%s/v1/%s/%s.min.json",
+		CatalogueURL:   "https://cdn.jsdelivr.net/npm/@fawazahmed0/currency-api@%s/v1/currencies.min.json",
+		FallbackURL:    "https://%s.currency-api.pages.dev/v1/%s/%s.min.json",
+		CacheDir:       defaultCacheDir(),
 		TimeoutSeconds: 10,
 	}
 }
 
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ffiii-rate-updater")
+}
+
 func (apiconfig *ApiConfig) GetURL(date string, currency string, endpoint string) string {
 	return fmt.Sprintf(apiconfig.URL, date, endpoint, currency)
 }
 
-// func (apiconfig *ApiConfig) GetFallbackURL(date string, currency string, endpoint string) string {
-// 	return fmt.Sprintf(apiconfig.FallbackURL, date, endpoint, currency)
-// }
+// snapshotCacheFile returns the cache path of a dated rates snapshot, or "" when it must not be cached.
+func (apiconfig *ApiConfig) snapshotCacheFile(currency string, date string) string {
+	if apiconfig.CacheDir == "" || date == "" || date == "latest" {
+		return ""
+	}
+	return filepath.Join(apiconfig.CacheDir, "rates", apiconfig.Name, date, currency+".json")
+}
+
+func (apiconfig *ApiConfig) GetCatalogueURL(date string) string {
+	return fmt.Sprintf(apiconfig.CatalogueURL, date)
+}
+
+func (apiconfig *ApiConfig) GetFallbackURL(date string, currency string, endpoint string) string {
+	return fmt.Sprintf(apiconfig.FallbackURL, date, endpoint, currency)
+}
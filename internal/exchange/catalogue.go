@@ -0,0 +1,187 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/textdist"
+)
+
+// CatalogueMaxAge is how long a cached currency catalogue is considered fresh.
+const CatalogueMaxAge = 24 * time.Hour
+
+// CatalogueEntry is a single currency known to the provider.
+type CatalogueEntry struct {
+	Code string
+	Name string
+}
+
+// Catalogue holds the currencies supported by the provider, keyed by upper-case code.
+type Catalogue map[string]string
+
+// LoadCatalogue returns the provider's currency catalogue.
+// A cached copy younger than CatalogueMaxAge is used when available, otherwise the catalogue is downloaded and cached.
+//
+// Parameters:
+//   - ctx: the context that cancels the download.
+//   - config: the provider configuration.
+//
+// Returns:
+//   - The Catalogue and an error if it could be neither read from the cache nor downloaded.
+func LoadCatalogue(ctx context.Context, config ApiConfig) (Catalogue, error) {
+
+	cacheFile := ""
+	if config.CacheDir != "" {
+		cacheFile = filepath.Join(config.CacheDir, config.Name+"-currencies.json")
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < CatalogueMaxAge {
+			body, err := os.ReadFile(cacheFile)
+			if err == nil {
+				if catalogue, err := parseCatalogue(body); err == nil {
+					return catalogue, nil
+				}
+			}
+		}
+	}
+
+	url := config.GetCatalogueURL("latest")
+
+	slog.Debug("Fetching currency catalogue", "provider", config.Name, "url", url)
+
+	body, err := download(ctx, config.HTTPClient, url, "", 0, config.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue, err := parseCatalogue(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(config.CacheDir, 0o755); err == nil {
+			err = os.WriteFile(cacheFile, body, 0o644)
+			if err != nil {
+				slog.Warn("Failed to cache currency catalogue", "provider", config.Name, "error", err)
+			}
+		}
+	}
+
+	return catalogue, nil
+}
+
+func parseCatalogue(body []byte) (Catalogue, error) {
+	var raw map[string]string
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse currency catalogue: %v", err)
+	}
+
+	catalogue := make(Catalogue, len(raw))
+	for code, name := range raw {
+		catalogue[strings.ToUpper(code)] = name
+	}
+	return catalogue, nil
+}
+
+// Has reports whether the provider supports the given currency code.
+func (c Catalogue) Has(code string) bool {
+	_, ok := c[strings.ToUpper(code)]
+	return ok
+}
+
+// Search returns the currencies whose code or name contains the query, sorted by code.
+// An empty query returns the whole catalogue.
+func (c Catalogue) Search(query string) []CatalogueEntry {
+	query = strings.ToLower(query)
+
+	var entries []CatalogueEntry
+	for code, name := range c {
+		if query == "" ||
+			strings.Contains(strings.ToLower(code), query) ||
+			strings.Contains(strings.ToLower(name), query) {
+			entries = append(entries, CatalogueEntry{Code: code, Name: name})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// Suggest returns up to limit codes that are the closest match to the given code.
+func (c Catalogue) Suggest(code string, limit int) []string {
+	code = strings.ToUpper(code)
+
+	type candidate struct {
+		code     string
+		distance int
+	}
+
+	var candidates []candidate
+	for known := range c {
+		d := textdist.Levenshtein(code, known)
+		// Prefixes like EURO -> EUR are likely typos as well
+		if strings.HasPrefix(code, known) || strings.HasPrefix(known, code) {
+			d = min(d, 1)
+		}
+		if d <= 2 {
+			candidates = append(candidates, candidate{code: known, distance: d})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].code < candidates[j].code
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		suggestions = append(suggestions, candidates[i].code)
+	}
+	return suggestions
+}
+
+// Validate checks that every code is supported by the provider.
+// The returned error lists all unknown codes together with the closest matches.
+func (c Catalogue) Validate(codes []string) error {
+	var problems []string
+	for _, code := range codes {
+		if c.Has(code) {
+			continue
+		}
+		problem := fmt.Sprintf("%q", code)
+		if suggestions := c.Suggest(code, 3); len(suggestions) > 0 {
+			problem += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, ", "))
+		}
+		problems = append(problems, problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unknown currencies: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testCatalogue = Catalogue{
+	"EUR": "Euro",
+	"USD": "US Dollar",
+	"AUD": "Australian Dollar",
+	"GBP": "British Pound",
+	"KGS": "Kyrgyzstani Som",
+	"XAU": "Gold Ounce",
+}
+
+func TestCatalogueSuggest(t *testing.T) {
+
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"EURO", []string{"EUR"}},
+		{"USX", []string{"USD"}},
+		// Closest first, equally close ones by code
+		{"XUD", []string{"AUD", "EUR"}},
+		{"xud", []string{"AUD", "EUR"}},
+		{"GPB", []string{"GBP"}},
+		{"KG", []string{"KGS"}},
+		{"ZZZZZ", nil},
+	}
+	for _, tt := range tests {
+		got := testCatalogue.Suggest(tt.code, 2)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+
+	if got := testCatalogue.Suggest("XUD", 1); !slices.Equal(got, []string{"AUD"}) {
+		t.Errorf("Suggest(XUD, 1) = %v, want the closest only", got)
+	}
+}
+
+func TestCatalogueValidate(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		codes []string
+		want  []string
+	}{
+		{"known", []string{"USD", "EUR"}, nil},
+		{"case-insensitive", []string{"usd", "Eur", "xau"}, nil},
+		{"typo", []string{"USD", "EURO"}, []string{`"EURO" (did you mean EUR?)`}},
+		{"every unknown code", []string{"GPB", "QQQQQ"}, []string{`"GPB" (did you mean GBP?)`, `"QQQQQ"`}},
+		{"lower-case unknown", []string{"kgz"}, []string{`"kgz" (did you mean KGS?)`}},
+	}
+	for _, tt := range tests {
+		err := testCatalogue.Validate(tt.codes)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		if want := "unknown currencies: " + strings.Join(tt.want, "; "); err.Error() != want {
+			t.Errorf("%s: error %q, want %q", tt.name, err, want)
+		}
+	}
+}
+
+func TestCatalogueSearch(t *testing.T) {
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"dollar", []string{"AUD", "USD"}},
+		{"eu", []string{"EUR"}},
+		{"", []string{"AUD", "EUR", "GBP", "KGS", "USD", "XAU"}},
+		{"peso", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range testCatalogue.Search(tt.query) {
+			got = append(got, e.Code)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errNotPublished is returned by a bank feed when there are no official rates for the requested date.
+var errNotPublished = errors.New("no official rates published")
+
+// bankFeed describes the official rate feed of a central bank.
+type bankFeed struct {
+	// name is the provider name used in the configuration
+	name string
+	// home is the currency the bank quotes all rates in
+	home string
+	// url is the default base URL of the feed
+	url string
+	// timezone is where the bank publishes its rates, it decides what "today" is
+	timezone string
+	// latestOnly is true when the feed has no historical rates
+	latestOnly bool
+	// effectiveDates is true when the feed returns the rates in effect on any date,
+	// including non-business days, so no fallback to earlier days is needed
+	effectiveDates bool
+	// fetch downloads the rates for the date ("" for the latest rates) and returns the
+	// publication date and the price of one unit of each currency in the home currency
+	fetch func(ctx context.Context, p *CentralBank, date string) (string, map[string]float64, error)
+}
+
+// CentralBank is the provider for the official rates published by a national central bank.
+// Banks quote foreign currencies in their home currency, often per 10 or 100 units; the
+// rates are normalised to one unit and rebased to the requested currency. Weekends and
+// configured holidays fall back to the previous business day.
+type CentralBank struct {
+	URL            string
+	TimeoutSeconds int
+	Location       *time.Location
+	Holidays       map[string]bool
+	HTTPClient     *http.Client
+
+	feed  bankFeed
+	cache map[string]ApiResponse
+	// mu serialises fetches, so concurrent requests for a date download it once
+	mu sync.Mutex
+}
+
+var bankFeeds = map[string]bankFeed{
+	"nbkr": nbkrFeed,
+	"nbp":  nbpFeed,
+	"cbr":  cbrFeed,
+}
+
+// NewCentralBank creates the provider for the central bank with the given name.
+func NewCentralBank(name string, config ProviderConfig) (*CentralBank, error) {
+	feed, ok := bankFeeds[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown central bank %q", name)
+	}
+
+	timezone := feed.timezone
+	if config.Timezone != "" {
+		timezone = config.Timezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q for %s: %v", timezone, name, err)
+	}
+
+	holidays := make(map[string]bool)
+	for _, day := range config.Holidays {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q for %s: %v", day, name, err)
+		}
+		holidays[day] = true
+	}
+
+	bank := &CentralBank{
+		URL:            feed.url,
+		TimeoutSeconds: 30,
+		Location:       location,
+		Holidays:       holidays,
+		HTTPClient:     config.HTTPClient,
+		feed:           feed,
+		cache:          make(map[string]ApiResponse),
+	}
+	if config.URL != "" {
+		bank.URL = strings.TrimSuffix(config.URL, "/")
+	}
+	if config.TimeoutSeconds > 0 {
+		bank.TimeoutSeconds = config.TimeoutSeconds
+	}
+	return bank, nil
+}
+
+// Name returns the provider name used in the configuration.
+func (p *CentralBank) Name() string {
+	return p.feed.name
+}
+
+// Catalogue returns the currencies of the latest official rates.
+func (p *CentralBank) Catalogue(ctx context.Context) (Catalogue, error) {
+	resp, err := p.fetch(ctx, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := Catalogue{p.feed.home: p.feed.home}
+	for code := range resp.Rates {
+		catalogue[code] = code
+	}
+	return catalogue, nil
+}
+
+// FetchRates returns the official rates rebased to the currency on the date.
+func (p *CentralBank) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	resp, err := p.fetch(ctx, date)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	// Home currency rates are the inverse of the published prices
+	homeRates := make(map[string]float64)
+	for code, price := range resp.Rates {
+		if price != 0 {
+			homeRates[code] = 1 / price
+		}
+	}
+
+	rates, err := rebase(homeRates, p.feed.home, strings.ToUpper(currency))
+	if err != nil {
+		return ApiResponse{}, fmt.Errorf("%v on %s", err, resp.Date)
+	}
+	return ApiResponse{Date: resp.Date, Rates: rates}, nil
+}
+
+// fetch returns the published prices for the date, stepping back over non-business days.
+func (p *CentralBank) fetch(ctx context.Context, date string) (ApiResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	today := time.Now().In(p.Location).Format("2006-01-02")
+	if date == "" || date == "latest" {
+		date = today
+	}
+	if p.feed.latestOnly && date != today {
+		return ApiResponse{}, fmt.Errorf("%s only publishes the latest rates", p.feed.name)
+	}
+
+	if resp, ok := p.cache[date]; ok {
+		return resp, nil
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ApiResponse{}, fmt.Errorf("invalid date %q: %v", date, err)
+	}
+
+	for i := 0; i <= ecbMaxGapDays; i++ {
+		d := day.AddDate(0, 0, -i)
+		if !p.feed.latestOnly && !p.feed.effectiveDates && !p.isBusinessDay(d) {
+			continue
+		}
+
+		requested := d.Format("2006-01-02")
+		if p.feed.latestOnly {
+			requested = ""
+		}
+
+		published, prices, err := p.feed.fetch(ctx, p, requested)
+		if errors.Is(err, errNotPublished) {
+			continue
+		}
+		if err != nil {
+			return ApiResponse{}, err
+		}
+
+		if p.feed.effectiveDates && !p.feed.latestOnly {
+			published = requested
+		}
+
+		resp := ApiResponse{Date: published, Rates: prices}
+		p.cache[date] = resp
+		return resp, nil
+	}
+
+	return ApiResponse{}, fmt.Errorf("%s: no official rates published on or before %s", p.feed.name, date)
+}
+
+func (p *CentralBank) isBusinessDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return !p.Holidays[day.Format("2006-01-02")]
+}
+
+// parseDecimal parses numbers that may use a decimal comma.
+func parseDecimal(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
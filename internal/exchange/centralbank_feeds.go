@@ -0,0 +1,196 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// nbkrFeed is the National Bank of the Kyrgyz Republic. Its daily XML only holds the current rates.
+var nbkrFeed = bankFeed{
+	name:       "nbkr",
+	home:       "KGS",
+	url:        "https://www.nbkr.kg/XML",
+	timezone:   "Asia/Bishkek",
+	latestOnly: true,
+	fetch: func(ctx context.Context, p *CentralBank, date string) (string, map[string]float64, error) {
+		body, err := download(ctx, p.HTTPClient, p.URL+"/daily.xml", "", 0, p.TimeoutSeconds)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var document struct {
+			Date       string `xml:"Date,attr"`
+			Currencies []struct {
+				Code    string `xml:"ISOCode,attr"`
+				Nominal string `xml:"Nominal"`
+				Value   string `xml:"Value"`
+			} `xml:"Currency"`
+		}
+		if err := xml.Unmarshal(body, &document); err != nil {
+			return "", nil, fmt.Errorf("failed to parse NBKR rates: %v", err)
+		}
+
+		published, err := time.Parse("02.01.2006", document.Date)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid NBKR date %q", document.Date)
+		}
+
+		prices := make(map[string]float64)
+		for _, c := range document.Currencies {
+			price, err := unitPrice(c.Value, c.Nominal)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid NBKR rate for %s: %v", c.Code, err)
+			}
+			prices[strings.ToUpper(c.Code)] = price
+		}
+		return published.Format("2006-01-02"), prices, nil
+	},
+}
+
+// nbpFeed is the National Bank of Poland. Table A is published on business days only,
+// other days answer 404.
+var nbpFeed = bankFeed{
+	name:     "nbp",
+	home:     "PLN",
+	url:      "https://api.nbp.pl/api/exchangerates/tables/A",
+	timezone: "Europe/Warsaw",
+	fetch: func(ctx context.Context, p *CentralBank, date string) (string, map[string]float64, error) {
+		url := p.URL + "/" + date + "/?format=json"
+		if date == "" {
+			url = p.URL + "/?format=json"
+		}
+
+		body, err := download(ctx, p.HTTPClient, url, "", 0, p.TimeoutSeconds)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return "", nil, errNotPublished
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		var tables []struct {
+			EffectiveDate string `json:"effectiveDate"`
+			Rates         []struct {
+				Code string  `json:"code"`
+				Mid  float64 `json:"mid"`
+			} `json:"rates"`
+		}
+		if err := json.Unmarshal(body, &tables); err != nil {
+			return "", nil, fmt.Errorf("failed to parse NBP rates: %v", err)
+		}
+		if len(tables) == 0 {
+			return "", nil, errNotPublished
+		}
+
+		prices := make(map[string]float64)
+		for _, r := range tables[0].Rates {
+			prices[strings.ToUpper(r.Code)] = r.Mid
+		}
+		return tables[0].EffectiveDate, prices, nil
+	},
+}
+
+// cbrFeed is the Central Bank of the Russian Federation. Its XML is windows-1251 encoded,
+// quotes some currencies per 10 or 100 units and answers any date with the rates in effect.
+var cbrFeed = bankFeed{
+	name:           "cbr",
+	home:           "RUB",
+	url:            "https://www.cbr.ru/scripts/XML_daily.asp",
+	timezone:       "Europe/Moscow",
+	effectiveDates: true,
+	fetch: func(ctx context.Context, p *CentralBank, date string) (string, map[string]float64, error) {
+		url := p.URL
+		if date != "" {
+			day, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				return "", nil, err
+			}
+			url += "?date_req=" + day.Format("02/01/2006")
+		}
+
+		body, err := download(ctx, p.HTTPClient, url, "", 0, p.TimeoutSeconds)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var document struct {
+			Date    string `xml:"Date,attr"`
+			Valutes []struct {
+				Code    string `xml:"CharCode"`
+				Nominal string `xml:"Nominal"`
+				Value   string `xml:"Value"`
+			} `xml:"Valute"`
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(body))
+		decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+			if strings.EqualFold(label, "windows-1251") {
+				return charmap.Windows1251.NewDecoder().Reader(input), nil
+			}
+			return nil, fmt.Errorf("unsupported charset %q", label)
+		}
+		if err := decoder.Decode(&document); err != nil {
+			return "", nil, fmt.Errorf("failed to parse CBR rates: %v", err)
+		}
+		if len(document.Valutes) == 0 {
+			return "", nil, errNotPublished
+		}
+
+		published, err := time.Parse("02.01.2006", document.Date)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid CBR date %q", document.Date)
+		}
+
+		prices := make(map[string]float64)
+		for _, v := range document.Valutes {
+			price, err := unitPrice(v.Value, v.Nominal)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid CBR rate for %s: %v", v.Code, err)
+			}
+			prices[strings.ToUpper(v.Code)] = price
+		}
+		return published.Format("2006-01-02"), prices, nil
+	},
+}
+
+// unitPrice returns the price of one unit from a price quoted per nominal units.
+func unitPrice(value string, nominal string) (float64, error) {
+	price, err := parseDecimal(value)
+	if err != nil {
+		return 0, err
+	}
+
+	units := 1.0
+	if strings.TrimSpace(nominal) != "" {
+		units, err = parseDecimal(nominal)
+		if err != nil || units <= 0 {
+			return 0, fmt.Errorf("invalid nominal %q", nominal)
+		}
+	}
+	return price / units, nil
+}
@@ -0,0 +1,220 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const nbkrTestDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<CurrencyRates Name="Official exchange rates" Date="02.01.2025">
+	<Currency ISOCode="USD"><Nominal>1</Nominal><Value>87,4500</Value></Currency>
+	<Currency ISOCode="KZT"><Nominal>100</Nominal><Value>16,6800</Value></Currency>
+	<Currency ISOCode="UZS"><Nominal>1000</Nominal><Value>6,7600</Value></Currency>
+</CurrencyRates>`
+
+const cbrTestDaily = `<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="%s" name="Foreign Currency Market">
+	<Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal><Name>Доллар США</Name><Value>101,6797</Value></Valute>
+	<Valute ID="R01820"><NumCode>392</NumCode><CharCode>JPY</CharCode><Nominal>100</Nominal><Name>Японских иен</Name><Value>64,5000</Value></Valute>
+</ValCurs>`
+
+const nbpTestTable = `[{"table":"A","no":"001/A/NBP/2025","effectiveDate":"%s","rates":[
+	{"currency":"dolar amerykański","code":"USD","mid":4.1012},
+	{"currency":"euro","code":"EUR","mid":4.2718}
+]}]`
+
+// bankTestServer serves the handler and records the requested paths and queries.
+type bankTestServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newBankTestServer(handler func(w http.ResponseWriter, r *http.Request)) *bankTestServer {
+	s := &bankTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.RequestURI())
+		s.mu.Unlock()
+		handler(w, r)
+	}))
+	return s
+}
+
+func (s *bankTestServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func TestCentralBankNBKRNominal(t *testing.T) {
+
+	server := newBankTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(nbkrTestDaily))
+	})
+	defer server.Close()
+
+	provider, err := NewCentralBank("nbkr", ProviderConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	resp, err := provider.FetchRates(ctx, "KGS", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Date != "2025-01-02" {
+		t.Errorf("date %s, want the published 2025-01-02", resp.Date)
+	}
+
+	tests := []struct {
+		code string
+		want float64
+	}{
+		{"USD", 1 / 87.45},
+		// KZT is quoted per 100 units, UZS per 1000
+		{"KZT", 100 / 16.68},
+		{"UZS", 1000 / 6.76},
+	}
+	for _, tt := range tests {
+		if math.Abs(resp.Rates[tt.code]-tt.want) > 1e-9 {
+			t.Errorf("KGS/%s = %v, want %v", tt.code, resp.Rates[tt.code], tt.want)
+		}
+	}
+
+	// Rebased to USD: one USD is worth 87.45 KGS and 87.45/0.1668 KZT
+	resp, err = provider.FetchRates(ctx, "usd", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(resp.Rates["KGS"]-87.45) > 1e-9 || math.Abs(resp.Rates["KZT"]-87.45/0.1668) > 1e-6 {
+		t.Errorf("USD rates %v, want KGS 87.45 and KZT %v", resp.Rates, 87.45/0.1668)
+	}
+
+	if _, err := provider.FetchRates(ctx, "KGS", "2025-01-02"); err == nil || !strings.Contains(err.Error(), "only publishes the latest") {
+		t.Errorf("historical fetch error %v, want latest only", err)
+	}
+	if got := server.Requests(); len(got) != 1 || got[0] != "/daily.xml" {
+		t.Errorf("requests %v, want one download of /daily.xml", got)
+	}
+}
+
+func TestCentralBankCBR(t *testing.T) {
+
+	server := newBankTestServer(func(w http.ResponseWriter, r *http.Request) {
+		// The bank answers with the date of the last publication, a Saturday has Friday's date
+		body, err := charmap.Windows1251.NewEncoder().String(fmt.Sprintf(cbrTestDaily, "28.12.2024"))
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=windows-1251")
+		w.Write([]byte(body))
+	})
+	defer server.Close()
+
+	provider, err := NewCentralBank("cbr", ProviderConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.FetchRates(context.Background(), "RUB", "2024-12-29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Any date has rates in effect, they are dated on the requested day
+	if resp.Date != "2024-12-29" {
+		t.Errorf("date %s, want 2024-12-29", resp.Date)
+	}
+	if math.Abs(resp.Rates["USD"]-1/101.6797) > 1e-12 {
+		t.Errorf("RUB/USD = %v, want %v", resp.Rates["USD"], 1/101.6797)
+	}
+	// JPY is quoted per 100 units
+	if math.Abs(resp.Rates["JPY"]-100/64.5) > 1e-9 {
+		t.Errorf("RUB/JPY = %v, want %v", resp.Rates["JPY"], 100/64.5)
+	}
+
+	if got := server.Requests(); !slices.Equal(got, []string{"/?date_req=29/12/2024"}) {
+		t.Errorf("requests %v, want one for 29/12/2024", got)
+	}
+}
+
+func TestCentralBankNBPFallback(t *testing.T) {
+
+	// Tables are published on 2025-01-02 and 2025-01-03 only
+	published := map[string]bool{"2025-01-02": true, "2025-01-03": true}
+	server := newBankTestServer(func(w http.ResponseWriter, r *http.Request) {
+		date := strings.Trim(r.URL.Path, "/")
+		if !published[date] {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, nbpTestTable, date)
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		date     string
+		holidays []string
+		want     string
+		requests []string
+	}{
+		{"published day", "2025-01-03", nil, "2025-01-03", []string{"/2025-01-03/?format=json"}},
+		// An unexpected 404 on a business day steps back to the previous one
+		{"unpublished business day", "2025-01-07", nil, "2025-01-03", []string{"/2025-01-07/?format=json", "/2025-01-06/?format=json", "/2025-01-03/?format=json"}},
+		// Weekends and configured holidays are skipped without a request
+		{"weekend", "2025-01-05", nil, "2025-01-03", []string{"/2025-01-03/?format=json"}},
+		{"holiday", "2025-01-06", []string{"2025-01-06"}, "2025-01-03", []string{"/2025-01-03/?format=json"}},
+		{"holiday before a 404", "2025-01-07", []string{"2025-01-06"}, "2025-01-03", []string{"/2025-01-07/?format=json", "/2025-01-03/?format=json"}},
+	}
+	for _, tt := range tests {
+		before := len(server.Requests())
+		provider, err := NewCentralBank("nbp", ProviderConfig{URL: server.URL, Holidays: tt.holidays})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := provider.FetchRates(context.Background(), "PLN", tt.date)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.Date != tt.want {
+			t.Errorf("%s: date %s, want %s", tt.name, resp.Date, tt.want)
+		}
+		if math.Abs(resp.Rates["USD"]-1/4.1012) > 1e-12 {
+			t.Errorf("%s: PLN/USD = %v, want %v", tt.name, resp.Rates["USD"], 1/4.1012)
+		}
+		if got := server.Requests()[before:]; !slices.Equal(got, tt.requests) {
+			t.Errorf("%s: requests %v, want %v", tt.name, got, tt.requests)
+		}
+	}
+
+	if _, err := NewCentralBank("nbp", ProviderConfig{Holidays: []string{"06.01.2025"}}); err == nil {
+		t.Error("expected an invalid holiday to fail")
+	}
+}
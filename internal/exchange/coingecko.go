@@ -0,0 +1,312 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CoinGeckoDefaultURL = "https://api.coingecko.com/api/v3"
+
+	TimeOfDayOpen  = "open"
+	TimeOfDayClose = "close"
+
+	// coinGeckoBridge is the coin used to convert between fiat currencies
+	coinGeckoBridge = "bitcoin"
+)
+
+// DefaultCoins maps common crypto currency codes to CoinGecko coin IDs.
+var DefaultCoins = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+	"BNB":  "binancecoin",
+	"SOL":  "solana",
+	"XRP":  "ripple",
+	"ADA":  "cardano",
+	"DOGE": "dogecoin",
+	"LTC":  "litecoin",
+	"TRX":  "tron",
+	"DOT":  "polkadot",
+}
+
+// CoinGecko is the provider for crypto prices from a CoinGecko-compatible API.
+//
+// Crypto markets never close, so a daily rate needs a time of day. CoinGecko's history
+// snapshot for a date is taken at 00:00 UTC, which is the open of that day. With
+// TimeOfDayClose (default) the rate for a date is the snapshot at 00:00 UTC of the next
+// day; for the current day, which has not closed yet, the current price is used.
+type CoinGecko struct {
+	URL            string
+	Headers        map[string]string
+	TimeoutSeconds int
+	CacheDir       string
+	HTTPClient     *http.Client
+	// Coins maps upper-case currency codes to coin IDs
+	Coins     map[string]string
+	TimeOfDay string
+
+	// selected are the requested currency codes, nil before SelectCurrencies
+	selected map[string]bool
+	// prices holds the loaded prices by snapshot date, coin ID and vs currency
+	prices map[string]map[string]map[string]float64
+	// mu serialises snapshot loads, so concurrent fetches download each snapshot once
+	mu sync.Mutex
+}
+
+// NewCoinGecko creates a new CoinGecko provider with the provided configuration.
+func NewCoinGecko(config ProviderConfig) (*CoinGecko, error) {
+	p := &CoinGecko{
+		URL:            CoinGeckoDefaultURL,
+		Headers:        config.Headers,
+		TimeoutSeconds: 30,
+		CacheDir:       defaultCacheDir(),
+		HTTPClient:     config.HTTPClient,
+		Coins:          make(map[string]string),
+		TimeOfDay:      TimeOfDayClose,
+		prices:         make(map[string]map[string]map[string]float64),
+	}
+	if config.URL != "" {
+		p.URL = strings.TrimSuffix(config.URL, "/")
+	}
+	if config.TimeoutSeconds > 0 {
+		p.TimeoutSeconds = config.TimeoutSeconds
+	}
+
+	switch strings.ToLower(config.TimeOfDay) {
+	case "", TimeOfDayClose:
+	case TimeOfDayOpen:
+		p.TimeOfDay = TimeOfDayOpen
+	default:
+		return nil, fmt.Errorf("invalid time_of_day %q, expected %q or %q", config.TimeOfDay, TimeOfDayOpen, TimeOfDayClose)
+	}
+
+	for code, id := range DefaultCoins {
+		p.Coins[code] = id
+	}
+	for code, id := range config.Coins {
+		p.Coins[strings.ToUpper(code)] = id
+	}
+	if _, ok := p.Coins["BTC"]; !ok {
+		p.Coins["BTC"] = coinGeckoBridge
+	}
+
+	return p, nil
+}
+
+// Name returns the provider name used in the configuration.
+func (p *CoinGecko) Name() string {
+	return "coingecko"
+}
+
+// SelectCurrencies limits the coins loaded to the requested currencies and the BTC bridge.
+// Without a selection every configured coin is loaded.
+func (p *CoinGecko) SelectCurrencies(codes []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.selected = make(map[string]bool)
+	for _, code := range codes {
+		p.selected[strings.ToUpper(code)] = true
+	}
+}
+
+// Catalogue returns the configured coins and the supported quote currencies.
+func (p *CoinGecko) Catalogue(ctx context.Context) (Catalogue, error) {
+	vs, err := p.vsCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := make(Catalogue)
+	for _, code := range vs {
+		catalogue[strings.ToUpper(code)] = code
+	}
+	for code, id := range p.Coins {
+		catalogue[code] = id
+	}
+	return catalogue, nil
+}
+
+// FetchRates returns the rates of the currency, a coin or a quote currency, on the date.
+func (p *CoinGecko) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	snapshot, resolved, err := p.snapshotDate(date)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	prices, err := p.load(ctx, snapshot)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	bridge, ok := prices[p.Coins["BTC"]]
+	if !ok || bridge["usd"] == 0 {
+		return ApiResponse{}, fmt.Errorf("no USD price for %s on %s", p.Coins["BTC"], resolved)
+	}
+
+	// Amount of every currency per one USD
+	usdRates := make(map[string]float64)
+	for vs, price := range bridge {
+		if price != 0 {
+			usdRates[strings.ToUpper(vs)] = price / bridge["usd"]
+		}
+	}
+	for code, id := range p.Coins {
+		if coin, ok := prices[id]; ok && coin["usd"] != 0 {
+			usdRates[code] = 1 / coin["usd"]
+		}
+	}
+
+	rates, err := rebase(usdRates, "USD", strings.ToUpper(currency))
+	if err != nil {
+		return ApiResponse{}, fmt.Errorf("%v on %s", err, resolved)
+	}
+	return ApiResponse{Date: resolved, Rates: rates}, nil
+}
+
+// snapshotDate returns the snapshot to load ("" for current prices) and the date of the rates.
+func (p *CoinGecko) snapshotDate(date string) (string, string, error) {
+	today := time.Now().UTC().Format("2006-01-02")
+	if date == "" || date == "latest" {
+		return "", today, nil
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid date %q: %v", date, err)
+	}
+
+	if p.TimeOfDay == TimeOfDayClose {
+		day = day.AddDate(0, 0, 1)
+	}
+	if day.Format("2006-01-02") > today {
+		return "", date, nil
+	}
+	return day.Format("2006-01-02"), date, nil
+}
+
+// load returns the prices of the selected coins and the bridge in all quote currencies for the
+// snapshot. Coins loaded before are not downloaded again.
+func (p *CoinGecko) load(ctx context.Context, snapshot string) (map[string]map[string]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prices, ok := p.prices[snapshot]
+	if !ok {
+		prices = make(map[string]map[string]float64)
+	}
+
+	ids := map[string]bool{p.Coins["BTC"]: true}
+	for code, id := range p.Coins {
+		if p.selected == nil || p.selected[code] {
+			ids[id] = true
+		}
+	}
+	var sortedIds []string
+	for id := range ids {
+		if _, loaded := prices[id]; !loaded {
+			sortedIds = append(sortedIds, id)
+		}
+	}
+	sort.Strings(sortedIds)
+	if len(sortedIds) == 0 {
+		return prices, nil
+	}
+
+	if snapshot == "" {
+		vs, err := p.vsCurrencies(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		query := url.Values{}
+		query.Set("ids", strings.Join(sortedIds, ","))
+		query.Set("vs_currencies", strings.Join(vs, ","))
+
+		body, err := downloadWithHeaders(ctx, p.HTTPClient, p.URL+"/simple/price?"+query.Encode(), p.Headers, "", 0, p.TimeoutSeconds)
+		if err != nil {
+			return nil, err
+		}
+		var current map[string]map[string]float64
+		if err := json.Unmarshal(body, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse prices: %v", err)
+		}
+		// Coins without a price are recorded too, so they are not requested again
+		for _, id := range sortedIds {
+			prices[id] = current[id]
+		}
+	} else {
+		day, _ := time.Parse("2006-01-02", snapshot)
+		for _, id := range sortedIds {
+			cacheFile := ""
+			if p.CacheDir != "" {
+				cacheFile = filepath.Join(p.CacheDir, "coingecko", snapshot, id+".json")
+			}
+
+			// Past snapshots never change, so the cache never expires
+			endpoint := fmt.Sprintf("%s/coins/%s/history?date=%s&localization=false", p.URL, url.PathEscape(id), day.Format("02-01-2006"))
+			body, err := downloadWithHeaders(ctx, p.HTTPClient, endpoint, p.Headers, cacheFile, 0, p.TimeoutSeconds)
+			if err != nil {
+				return nil, err
+			}
+
+			var history struct {
+				MarketData struct {
+					CurrentPrice map[string]float64 `json:"current_price"`
+				} `json:"market_data"`
+			}
+			if err := json.Unmarshal(body, &history); err != nil {
+				return nil, fmt.Errorf("failed to parse history of %s: %v", id, err)
+			}
+			prices[id] = history.MarketData.CurrentPrice
+		}
+	}
+
+	p.prices[snapshot] = prices
+	return prices, nil
+}
+
+// vsCurrencies returns the quote currencies supported by the API, cached for a day.
+func (p *CoinGecko) vsCurrencies(ctx context.Context) ([]string, error) {
+	cacheFile := ""
+	if p.CacheDir != "" {
+		cacheFile = filepath.Join(p.CacheDir, "coingecko", "supported_vs_currencies.json")
+	}
+
+	body, err := downloadWithHeaders(ctx, p.HTTPClient, p.URL+"/simple/supported_vs_currencies", p.Headers, cacheFile, CatalogueMaxAge, p.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	var vs []string
+	if err := json.Unmarshal(body, &vs); err != nil {
+		return nil, fmt.Errorf("failed to parse supported currencies: %v", err)
+	}
+	return vs, nil
+}
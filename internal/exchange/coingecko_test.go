@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCoinGeckoSelectedCoins(t *testing.T) {
+
+	usd := map[string]float64{"bitcoin": 100000, "ethereum": 4000}
+
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/coins/"), "/history")
+		price, ok := usd[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"market_data": {"current_price": {"usd": %v, "eur": %v}}}`, price, price*0.9)
+	}))
+	defer server.Close()
+
+	provider, err := NewCoinGecko(ProviderConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.CacheDir = ""
+	provider.SelectCurrencies([]string{"usd", "eur", "eth"})
+
+	for _, date := range []string{"2025-01-01", "2025-01-02"} {
+		resp, err := provider.FetchRates(context.Background(), "eth", date)
+		if err != nil {
+			t.Fatalf("%s: %v", date, err)
+		}
+		if math.Abs(resp.Rates["USD"]-4000) > 1e-9 || math.Abs(resp.Rates["EUR"]-3600) > 1e-9 {
+			t.Errorf("%s: got %v, want USD 4000 and EUR 3600", date, resp.Rates)
+		}
+	}
+	// The second base of the same date is served from the loaded prices
+	if _, err := provider.FetchRates(context.Background(), "usd", "2025-01-02"); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(requested)
+	want := []string{"/coins/bitcoin/history", "/coins/bitcoin/history", "/coins/ethereum/history", "/coins/ethereum/history"}
+	if strings.Join(requested, " ") != strings.Join(want, " ") {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+}
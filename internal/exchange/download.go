@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StatusError is returned when a download fails with an unexpected HTTP status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to download %s: %s", e.URL, e.Status)
+}
+
+// download fetches the url with the client (http.DefaultClient when nil) and returns the response body.
+// The request is cancelled with ctx or after timeoutSeconds, whichever comes first.
+// When cacheFile is set, a cached copy younger than maxAge is returned instead, and
+// fresh downloads are written to the cache. A maxAge of zero never expires the cache.
+func download(ctx context.Context, client *http.Client, url string, cacheFile string, maxAge time.Duration, timeoutSeconds int) ([]byte, error) {
+	return downloadWithHeaders(ctx, client, url, nil, cacheFile, maxAge, timeoutSeconds)
+}
+
+// downloadWithHeaders is download with additional request headers, e.g. an API key.
+func downloadWithHeaders(ctx context.Context, client *http.Client, url string, headers map[string]string, cacheFile string, maxAge time.Duration, timeoutSeconds int) ([]byte, error) {
+
+	if cacheFile != "" {
+		if info, err := os.Stat(cacheFile); err == nil && (maxAge == 0 || time.Since(info.ModTime()) < maxAge) {
+			if body, err := os.ReadFile(cacheFile); err == nil {
+				return body, nil
+			}
+		}
+	}
+
+	slog.Debug("Downloading", "url", url)
+
+	if timeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	slog.Debug("Downloaded", "url", url, "status", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0o755); err == nil {
+			if err := os.WriteFile(cacheFile, body, 0o644); err != nil {
+				slog.Warn("Failed to cache download", "url", url, "error", err)
+			}
+		}
+	}
+
+	return body, nil
+}
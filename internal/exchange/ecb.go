@@ -0,0 +1,323 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ECBDefaultURL = "https://www.ecb.europa.eu/stats/eurofxref"
+	ECBDaily      = "eurofxref-daily.xml"
+	ECB90Days     = "eurofxref-hist-90d.xml"
+	ECBHistory    = "eurofxref-hist.zip"
+
+	// ecbMaxGapDays is how far back a weekend or holiday falls back to the previous reference rate.
+	ecbMaxGapDays = 7
+	// ecb90DaysWindow is the age up to which a date is looked up in the 90-day feed.
+	ecb90DaysWindow = 85
+)
+
+// ECB is the provider for the euro foreign exchange reference rates of the European Central Bank.
+// The rates are quoted against EUR and rebased to the requested currency.
+// Dates without reference rates (weekends and TARGET holidays) use the previous published rates.
+type ECB struct {
+	URL            string
+	CacheDir       string
+	TimeoutSeconds int
+	HTTPClient     *http.Client
+
+	// tables holds the loaded reference rates by feed file and date
+	tables map[string]map[string]map[string]float64
+	// mu serialises feed loads, so concurrent fetches download each feed once
+	mu sync.Mutex
+}
+
+// NewECB creates a new ECB provider with the provided configuration.
+func NewECB(config ProviderConfig) *ECB {
+	ecb := &ECB{
+		URL:            ECBDefaultURL,
+		CacheDir:       defaultCacheDir(),
+		TimeoutSeconds: 30,
+		HTTPClient:     config.HTTPClient,
+		tables:         make(map[string]map[string]map[string]float64),
+	}
+	if config.URL != "" {
+		ecb.URL = strings.TrimSuffix(config.URL, "/")
+	}
+	if config.TimeoutSeconds > 0 {
+		ecb.TimeoutSeconds = config.TimeoutSeconds
+	}
+	return ecb
+}
+
+// Name returns the provider name used in the configuration.
+func (p *ECB) Name() string {
+	return "ecb"
+}
+
+// Catalogue returns the currencies of the latest reference rates.
+func (p *ECB) Catalogue(ctx context.Context) (Catalogue, error) {
+	table, err := p.load(ctx, ECBDaily)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := Catalogue{"EUR": "EUR"}
+	for _, rates := range table {
+		for code := range rates {
+			catalogue[code] = code
+		}
+	}
+	return catalogue, nil
+}
+
+// CatalogueOn returns the currencies of the reference rates published on the date, or on the
+// closest earlier business day, so currencies the ECB no longer publishes (e.g. HRK or RUB)
+// are valid for the dates they were published on.
+func (p *ECB) CatalogueOn(ctx context.Context, date string) (Catalogue, error) {
+	_, rates, err := p.lookup(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := Catalogue{"EUR": "EUR"}
+	for code := range rates {
+		catalogue[code] = code
+	}
+	return catalogue, nil
+}
+
+// FetchRates returns the reference rates rebased to the currency on the date.
+func (p *ECB) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	currency = strings.ToUpper(currency)
+
+	refDate, eurRates, err := p.lookup(ctx, date)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	rates, err := rebase(eurRates, "EUR", currency)
+	if err != nil {
+		return ApiResponse{}, fmt.Errorf("%v on %s", err, refDate)
+	}
+
+	return ApiResponse{Date: refDate, Rates: rates}, nil
+}
+
+// lookup returns the date and the EUR rates published on the date, from the smallest feed covering it.
+func (p *ECB) lookup(ctx context.Context, date string) (string, map[string]float64, error) {
+
+	feed := ECBHistory
+	if date == "" || date == "latest" {
+		feed = ECBDaily
+	} else {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date %q: %v", date, err)
+		}
+		if time.Since(day) < ecb90DaysWindow*24*time.Hour {
+			feed = ECB90Days
+		}
+	}
+
+	table, err := p.load(ctx, feed)
+	if err != nil {
+		return "", nil, err
+	}
+	return ecbLookup(table, date)
+}
+
+// rebase converts rates quoted against one base currency to rates quoted against another.
+func rebase(rates map[string]float64, from string, to string) (map[string]float64, error) {
+	if to == from {
+		return rates, nil
+	}
+
+	baseRate, ok := rates[to]
+	if !ok || baseRate == 0 {
+		return nil, fmt.Errorf("no rate for %s", to)
+	}
+
+	rebased := map[string]float64{from: 1 / baseRate}
+	for code, rate := range rates {
+		if code == to {
+			continue
+		}
+		rebased[code] = rate / baseRate
+	}
+	return rebased, nil
+}
+
+// ecbLookup returns the rates published on the date, or on the closest earlier business day.
+func ecbLookup(table map[string]map[string]float64, date string) (string, map[string]float64, error) {
+	if date == "" || date == "latest" {
+		latest := ""
+		for d := range table {
+			if d > latest {
+				latest = d
+			}
+		}
+		if latest == "" {
+			return "", nil, fmt.Errorf("no ECB reference rates published")
+		}
+		return latest, table[latest], nil
+	}
+
+	day, _ := time.Parse("2006-01-02", date)
+	for i := 0; i <= ecbMaxGapDays; i++ {
+		d := day.AddDate(0, 0, -i).Format("2006-01-02")
+		if rates, ok := table[d]; ok {
+			return d, rates, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no ECB reference rates published on or before %s", date)
+}
+
+// load downloads and parses a feed once per run. Feeds are cached on disk for a day.
+func (p *ECB) load(ctx context.Context, feed string) (map[string]map[string]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if table, ok := p.tables[feed]; ok {
+		return table, nil
+	}
+
+	cacheFile := ""
+	if p.CacheDir != "" && feed != ECBDaily {
+		cacheFile = filepath.Join(p.CacheDir, "ecb", feed)
+	}
+
+	body, err := download(ctx, p.HTTPClient, p.URL+"/"+feed, cacheFile, CatalogueMaxAge, p.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	var table map[string]map[string]float64
+	if feed == ECBHistory {
+		table, err = parseECBHistory(body)
+	} else {
+		table, err = parseECBXML(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", feed, err)
+	}
+
+	p.tables[feed] = table
+	return table, nil
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// parseECBXML parses the daily and 90-day XML feeds.
+func parseECBXML(body []byte) (map[string]map[string]float64, error) {
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]map[string]float64)
+	for _, day := range envelope.Days {
+		rates := make(map[string]float64)
+		for _, r := range day.Rates {
+			rates[strings.ToUpper(r.Currency)] = r.Rate
+		}
+		table[day.Time] = rates
+	}
+	return table, nil
+}
+
+// parseECBHistory parses the zipped history CSV: a Date column followed by one column per currency.
+func parseECBHistory(body []byte) (map[string]map[string]float64, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range archive.File {
+		files = append(files, f.Name)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("empty archive")
+	}
+
+	f, err := archive.Open(files[0])
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseECBCSV(f)
+}
+
+func parseECBCSV(r io.Reader) (map[string]map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]map[string]float64)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rates := make(map[string]float64)
+		for i := 1; i < len(record) && i < len(header); i++ {
+			code := strings.ToUpper(strings.TrimSpace(header[i]))
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			// Currencies without a rate on the day are marked N/A
+			if code == "" || err != nil {
+				continue
+			}
+			rates[code] = value
+		}
+		table[strings.TrimSpace(record[0])] = rates
+	}
+	return table, nil
+}
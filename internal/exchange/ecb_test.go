@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const ecbTestDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2025-01-02">
+			<Cube currency="USD" rate="1.0321"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbTestHistory = `Date,USD,HRK,
+2020-01-02,1.1193,7.4415,
+2019-12-31,1.1234,7.4395,
+`
+
+func ecbTestServer(t *testing.T) *httptest.Server {
+
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	f, err := w.Create("eurofxref-hist.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(ecbTestHistory))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+ECBDaily, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ecbTestDaily))
+	})
+	mux.HandleFunc("/"+ECBHistory, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestECBHistoricalCurrency(t *testing.T) {
+
+	server := ecbTestServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		date  string
+		valid bool
+	}{
+		// HRK is only in the history feed, it was replaced by EUR in 2023
+		{"2020-01-02", true},
+		{"2020-01-04", true},
+		{"latest", false},
+	}
+	for _, tt := range tests {
+		provider := NewECB(ProviderConfig{URL: server.URL})
+		provider.CacheDir = ""
+
+		api, err := NewApi(ctx, provider, []string{"USD", "HRK"}, tt.date, nil, nil, 2)
+		if (err == nil) != tt.valid {
+			t.Fatalf("%s: NewApi() = %v, want valid %v", tt.date, err, tt.valid)
+		}
+		if err != nil {
+			continue
+		}
+
+		rate, err := api.GetRate("USD", "HRK")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.date, err)
+		}
+		if want := 7.4415 / 1.1193; math.Abs(rate.Value-want) > 1e-9 {
+			t.Errorf("%s: USD/HRK = %v, want %v", tt.date, rate.Value, want)
+		}
+	}
+}
@@ -16,52 +16,133 @@ limitations under the License.
 package exchange
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"log/slog"
 	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/limit"
 )
 
 type Api struct {
-	Config ApiConfig
-	Rates  []Rate
+	Provider   Provider
+	Mapping    CurrencyMap
+	Currencies []Currency
+	// Date is the date of the fetched rates as reported by the provider.
+	Date  string
+	Rates []Rate
+	// Coverage lists the days a period average is based on, nil for daily rates.
+	Coverage *Coverage
 }
 
 type ApiResponse struct {
-	Date  string
-	Rates map[string]float64
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+	// Source is where the rates were served from, e.g. the URL of a mirror or "cache".
+	// It is informational only and may be empty.
+	Source string `json:"source,omitempty"`
 }
 
 // NewApi creates a new Api instance with exchange rates for the specified currencies and date.
 //
 // Parameters:
+//   - ctx: the context that cancels the provider requests.
+//   - provider: the source of the exchange rates.
 //   - rawCurrencies: a slice of currency codes (e.g., "USD", "EUR") for which to fetch exchange rates.
 //   - date: the date (in string format, e.g., "2024-06-01") for which to retrieve the exchange rates.
+//   - mappings: the configured currency code mappings between Firefly III and the providers.
+//   - overrides: the configured manual rates and pegs, merged into the fetched rates.
+//   - concurrency: the maximum number of base currencies fetched at the same time.
 //
 // Returns:
 //
 //	A pointer to an Api struct initialized with the requested exchange rates.
-func NewApi(rawCurrencies []string, date string) (*Api, error) {
+func NewApi(ctx context.Context, provider Provider, rawCurrencies []string, date string, mappings []CurrencyMapping, overrides []Override, concurrency int) (*Api, error) {
 
 	api := Api{
-		Config: GetApiConfig(),
+		Provider: provider,
+	}
+
+	mapping, err := NewCurrencyMap(mappings, provider.Name())
+	if err != nil {
+		return nil, err
 	}
+	api.Mapping = mapping
 
 	// Convert rawCurrencies to []Currency
 	var exCurrencies []Currency
 	for _, curr := range rawCurrencies {
-		exCurrencies = append(exCurrencies, NewCurrency(curr))
+		exCurrencies = append(exCurrencies, api.Mapping.Currency(curr))
+	}
+	api.Currencies = exCurrencies
+
+	// Permanently pegged currencies are derived from their peg and not fetched
+	pegged := make(map[Currency]bool)
+	for _, o := range overrides {
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+		if o.IsPermanentPeg() {
+			pegged[api.Mapping.Currency(o.From)] = true
+		}
+	}
+	var fetchCurrencies []Currency
+	for _, curr := range exCurrencies {
+		if !pegged[curr] {
+			fetchCurrencies = append(fetchCurrencies, curr)
+		}
+	}
+
+	if selector, ok := provider.(CurrencySelector); ok {
+		var codes []string
+		for _, curr := range fetchCurrencies {
+			codes = append(codes, api.Mapping.ProviderCode(curr))
+		}
+		selector.SelectCurrencies(codes)
+	}
+
+	// Validate currencies up front, a missing catalogue should not block the update
+	var catalogue Catalogue
+	if dated, ok := provider.(DatedCatalogue); ok && date != "" && date != "latest" {
+		catalogue, err = dated.CatalogueOn(ctx, date)
+	} else {
+		catalogue, err = provider.Catalogue(ctx)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if errors.Is(err, ErrNoCatalogue) {
+		slog.Debug("Provider has no currency catalogue, currencies are not validated", "provider", provider.Name())
+	} else if err != nil {
+		slog.Warn("Skipping currency validation", "provider", provider.Name(), "error", err)
+	} else {
+		var codes []string
+		for _, curr := range fetchCurrencies {
+			codes = append(codes, api.Mapping.ProviderCode(curr))
+		}
+		if err := catalogue.Validate(codes); err != nil {
+			return nil, err
+		}
 	}
 
 	// Initialize exchange rates
-	rates, err := api.getExchangeRates(exCurrencies, date)
+	rates, err := api.getExchangeRates(ctx, fetchCurrencies, date, concurrency)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing API rates: %v", err)
 	}
 
 	api.Rates = rates
+	if len(rates) > 0 {
+		api.Date = rates[0].Date
+	} else if date == "" || date == "latest" {
+		api.Date = time.Now().Format("2006-01-02")
+	} else {
+		api.Date = date
+	}
+
+	if err := api.ApplyOverrides(overrides); err != nil {
+		return nil, fmt.Errorf("error applying overrides: %v", err)
+	}
 
 	return &api, nil
 }
@@ -80,7 +161,7 @@ func (api *Api) GetRate(from string, to string) (Rate, error) {
 	var rate Rate
 
 	found := false
-	pair := Pair{From: NewCurrency(from), To: NewCurrency(to)}
+	pair := Pair{From: api.Mapping.Currency(from), To: api.Mapping.Currency(to)}
 	for _, r := range api.Rates {
 		if r.Pair == pair {
 			rate = r
@@ -96,105 +177,41 @@ func (api *Api) GetRate(from string, to string) (Rate, error) {
 	return rate, nil
 }
 
-func (api *Api) getExchangeRates(currencies []Currency, date string) ([]Rate, error) {
+// getExchangeRates fetches the rates of the currencies on a pool of at most concurrency workers.
+// The rates are returned in the order of the currencies.
+func (api *Api) getExchangeRates(ctx context.Context, currencies []Currency, date string, concurrency int) ([]Rate, error) {
 
-	rates := []Rate{}
+	responses := make([]ApiResponse, len(currencies))
+	err := limit.Run(ctx, concurrency, len(currencies), func(ctx context.Context, i int) error {
+		resp, err := api.Provider.FetchRates(ctx, api.Mapping.ProviderCode(currencies[i]), date)
+		responses[i] = resp
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// fetch exchange rates for the given currencies
-	for _, currency := range currencies {
-		resp, err := api.fetchRates(currency.GetLCode(), date)
-		if err != nil {
-			return nil, err
-		}
+	rates := []Rate{}
+	for i, currency := range currencies {
+		resp := responses[i]
 
+		fromMultiplier := api.Mapping.Multiplier(currency)
 		for k, v := range resp.Rates {
-
-			rates = append(rates, Rate{
-				Date: resp.Date,
-				Pair: Pair{
-					From: currency,
-					To:   NewCurrency(k),
-				},
-				Value: v,
-			})
+			// Rates are quoted in provider units, convert them to Firefly units
+			for _, to := range api.Mapping.FireflyCurrencies(k) {
+				rates = append(rates, Rate{
+					Date: resp.Date,
+					Pair: Pair{
+						From: currency,
+						To:   to,
+					},
+					Value:  v * api.Mapping.Multiplier(to) / fromMultiplier,
+					Source: resp.Source,
+				})
+			}
 		}
 
 	}
 
 	return rates, nil
 }
-
-func (api *Api) fetchRates(currency string, date string) (response ApiResponse, err error) {
-
-	if date == "" {
-		date = "latest"
-	}
-
-	// TODO: Implement fallback mechanism
-	url := api.Config.GetURL(date, currency, "currencies")
-
-	log.Printf("Fetching rates for %s on %s", currency, date)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return ApiResponse{}, err
-	}
-
-	client := &http.Client{Timeout: time.Duration(api.Config.TimeoutSeconds) * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return ApiResponse{}, err
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return ApiResponse{}, fmt.Errorf("failed to fetch rates: %s", resp.Status)
-	}
-
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return ApiResponse{}, err
-	}
-
-	var rawJson map[string]interface{}
-	err = json.Unmarshal(body, &rawJson)
-	if err != nil {
-		return ApiResponse{}, err
-	}
-
-	// Extract date and rates
-	// Safely extract "date" as string
-	rawDate, ok := rawJson["date"]
-	if !ok {
-		return ApiResponse{}, fmt.Errorf("missing 'date' field in API response")
-	}
-	date, ok = rawDate.(string)
-	if !ok {
-		return ApiResponse{}, fmt.Errorf("'date' field is not a string in API response")
-	}
-
-	// Safely extract rates map
-	rawRates, ok := rawJson[currency]
-	if !ok {
-		return ApiResponse{}, fmt.Errorf("missing '%s' field in API response", currency)
-	}
-	ratesMap, ok := rawRates.(map[string]any)
-	if !ok {
-		return ApiResponse{}, fmt.Errorf("'%s' field is not a map in API response", currency)
-	}
-	var rates = make(map[string]float64)
-	for key, value := range ratesMap {
-		floatVal, ok := value.(float64)
-		if !ok {
-			return ApiResponse{}, fmt.Errorf("rate for '%s' is not a float64 in API response", key)
-		}
-		rates[key] = floatVal
-	}
-
-	return ApiResponse{
-		Date:  date,
-		Rates: rates,
-	}, nil
-}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewApiSource(t *testing.T) {
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2025-01-02/v1/currencies/eur.min.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"date": "2025-01-02", "eur": {"usd": 1.04}}`))
+	}))
+	defer fallback.Close()
+
+	config := ProviderConfig{URL: primary.URL, FallbackURL: fallback.URL}
+	provider, err := NewProvider(DefaultProvider, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsdelivr := provider.(*JsDelivr)
+	jsdelivr.Config.CacheDir = t.TempDir()
+
+	cached := jsdelivr.Config.snapshotCacheFile("usd", "2025-01-02")
+	if err := os.MkdirAll(filepath.Dir(cached), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cached, []byte(`{"date": "2025-01-02", "usd": {"eur": 0.96}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err := NewApi(context.Background(), provider, []string{"USD", "EUR"}, "2025-01-02", nil, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		source   string
+	}{
+		{"USD", "EUR", "cache"},
+		{"EUR", "USD", fallback.URL + "/2025-01-02/v1/currencies/eur.min.json"},
+	}
+	for _, tt := range tests {
+		rate, err := api.GetRate(tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate.Source != tt.source {
+			t.Errorf("%s/%s: source = %q, want %q", tt.from, tt.to, rate.Source, tt.source)
+		}
+		if strings.HasPrefix(rate.Source, primary.URL) {
+			t.Errorf("%s/%s: served from the failing primary", tt.from, tt.to)
+		}
+	}
+}
+
+func TestFetchRatesCancel(t *testing.T) {
+
+	// The server never answers, only a cancelled context ends the requests
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	for _, name := range []string{"jsdelivr", "ecb", "nbp", "cbr"} {
+		provider, err := NewProvider(name, ProviderConfig{URL: server.URL, TimeoutSeconds: 30})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ecb, ok := provider.(*ECB); ok {
+			ecb.CacheDir = ""
+		}
+		if jsdelivr, ok := provider.(*JsDelivr); ok {
+			jsdelivr.Config.CacheDir = ""
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err = provider.FetchRates(ctx, "EUR", "2025-01-02")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: error %v, want context.Canceled", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: returned after %v, want promptly", name, elapsed)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exec is the provider that runs an external command to get the rates.
+//
+// The command receives the base currency, the date and the requested currencies as
+// placeholders in its arguments ({base}, {date}, {currencies}), as the environment
+// variables FFIII_BASE, FFIII_DATE and FFIII_CURRENCIES (comma-separated), and as a JSON
+// document on stdin:
+//
+//	{"base": "USD", "date": "2025-01-01", "currencies": ["USD", "EUR", "GBP"]}
+//
+// The date is "latest" when no date was requested. It must print a JSON document to stdout:
+//
+//	{"date": "2025-01-01", "rates": {"EUR": 0.92, "GBP": 0.79}}
+//
+// where the rates are the value of one base currency unit in each currency.
+// Anything printed to stderr is logged, and included in the error when the command fails.
+type Exec struct {
+	Command        []string
+	TimeoutSeconds int
+
+	currencies []string
+	mu         sync.Mutex
+}
+
+// NewExec creates a new Exec provider with the provided configuration.
+func NewExec(config ProviderConfig) (*Exec, error) {
+	if len(config.Command) == 0 {
+		return nil, fmt.Errorf("exec provider requires a command")
+	}
+
+	p := &Exec{
+		Command:        config.Command,
+		TimeoutSeconds: 30,
+	}
+	if config.TimeoutSeconds > 0 {
+		p.TimeoutSeconds = config.TimeoutSeconds
+	}
+	return p, nil
+}
+
+// Name returns the provider name used in the configuration.
+func (p *Exec) Name() string {
+	return "exec"
+}
+
+// SelectCurrencies receives the requested currencies passed to the command.
+func (p *Exec) SelectCurrencies(codes []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.currencies = codes
+}
+
+// Catalogue is not available for external commands, it returns ErrNoCatalogue.
+func (p *Exec) Catalogue(ctx context.Context) (Catalogue, error) {
+	return nil, ErrNoCatalogue
+}
+
+// execRequest is the JSON document written to the stdin of the command.
+type execRequest struct {
+	Base       string   `json:"base"`
+	Date       string   `json:"date"`
+	Currencies []string `json:"currencies"`
+}
+
+// FetchRates runs the command for the currency and date and parses its output.
+func (p *Exec) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	if date == "" {
+		date = "latest"
+	}
+
+	p.mu.Lock()
+	selected := append([]string{}, p.currencies...)
+	p.mu.Unlock()
+	currencies := strings.Join(selected, ",")
+
+	request, err := json.Marshal(execRequest{Base: currency, Date: date, Currencies: selected})
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	replacer := strings.NewReplacer(
+		"{base}", currency,
+		"{date}", date,
+		"{currencies}", currencies,
+	)
+	var args []string
+	for _, arg := range p.Command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Command[0], args...)
+	cmd.Env = append(os.Environ(),
+		"FFIII_BASE="+currency,
+		"FFIII_DATE="+date,
+		"FFIII_CURRENCIES="+currencies,
+	)
+
+	// A command that is killed may leave children holding its output open
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	slog.Info("Running command", "provider", p.Name(), "command", p.Command[0], "base", currency, "date", date)
+
+	err = cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		slog.Info("Command output", "provider", p.Name(), "command", p.Command[0], "stderr", msg)
+	}
+	if ctx.Err() == context.Canceled {
+		return ApiResponse{}, ctx.Err()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return ApiResponse{}, fmt.Errorf("%s timed out after %d seconds", p.Command[0], p.TimeoutSeconds)
+	}
+	if err != nil {
+		return ApiResponse{}, fmt.Errorf("%s failed: %v: %s", p.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp ApiResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return ApiResponse{}, fmt.Errorf("failed to parse output of %s: %v", p.Command[0], err)
+	}
+	if resp.Date == "" {
+		return ApiResponse{}, fmt.Errorf("missing 'date' field in output of %s", p.Command[0])
+	}
+	if _, err := time.Parse("2006-01-02", resp.Date); err != nil {
+		return ApiResponse{}, fmt.Errorf("invalid 'date' %q in output of %s", resp.Date, p.Command[0])
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecInput(t *testing.T) {
+
+	dir := t.TempDir()
+	script := `cat > "$0/stdin.json"; printf '%s %s %s' "$1" "$2" "$FFIII_BASE,$FFIII_DATE,$FFIII_CURRENCIES" > "$0/args"; ` +
+		`echo '{"date": "2025-01-02", "rates": {"EUR": 0.96, "GBP": 0.8}}'`
+	provider, err := NewExec(ProviderConfig{Command: []string{"sh", "-c", script, dir, "{base}", "{date}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.SelectCurrencies([]string{"USD", "EUR", "GBP"})
+
+	resp, err := provider.FetchRates(context.Background(), "USD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Date != "2025-01-02" || resp.Rates["EUR"] != 0.96 || resp.Rates["GBP"] != 0.8 {
+		t.Errorf("response %+v, want the rates of 2025-01-02", resp)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(args) != "USD latest USD,latest,USD,EUR,GBP" {
+		t.Errorf("arguments and environment %q", args)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request execRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("stdin %s: %v", body, err)
+	}
+	if request.Base != "USD" || request.Date != "latest" || !slices.Equal(request.Currencies, []string{"USD", "EUR", "GBP"}) {
+		t.Errorf("stdin %+v", request)
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+		absent string
+	}{
+		{"exit status", `echo '{"date": "2025-01-02", "rates": {}}'; echo 'broker export missing' >&2; exit 3`, "exit status 3: broker export missing", "rates"},
+		{"malformed output", `echo 'EUR=0.96'`, "failed to parse output", ""},
+		{"missing date", `echo '{"rates": {"EUR": 0.96}}'`, "missing 'date'", ""},
+		{"invalid date", `echo '{"date": "02.01.2025", "rates": {"EUR": 0.96}}'`, "invalid 'date'", ""},
+		// The shell is killed, its sleeping child still holds stdout
+		{"timeout", `sleep 10; echo '{}'`, "timed out after 1 seconds", ""},
+	}
+	for _, tt := range tests {
+		provider, err := NewExec(ProviderConfig{Command: []string{"sh", "-c", tt.script}, TimeoutSeconds: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Now()
+		_, err = provider.FetchRates(context.Background(), "USD", "2025-01-02")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.want)
+		}
+		if err != nil && tt.absent != "" && strings.Contains(err.Error(), tt.absent) {
+			t.Errorf("%s: error %q includes stdout", tt.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: took %v", tt.name, elapsed)
+		}
+	}
+
+	// A cancelled run is reported as such, not as a failed command
+	ctx, cancel := context.WithCancel(context.Background())
+	provider, _ := NewExec(ProviderConfig{Command: []string{"sh", "-c", "sleep 10"}})
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := provider.FetchRates(ctx, "USD", "latest"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled command: error %v, want context.Canceled", err)
+	}
+}
+
+func TestExecNoCatalogue(t *testing.T) {
+
+	provider, err := NewExec(ProviderConfig{Command: []string{"sh", "-c", `echo '{"date": "2025-01-02", "rates": {"EUR": 0.96, "USD": 1.04}}'`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Catalogue(context.Background()); !errors.Is(err, ErrNoCatalogue) {
+		t.Errorf("catalogue error %v, want ErrNoCatalogue", err)
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if _, err := NewApi(context.Background(), provider, []string{"USD", "EUR"}, "2025-01-02", nil, nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(logs.String(), "currency validation") {
+		t.Errorf("missing catalogue logged as a warning: %s", logs.String())
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileRate is a single rate record of the file provider: one From is worth Rate To on Date.
+type FileRate struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// File is the provider that reads rates from local files.
+//
+// Path is a CSV file with the columns date, from, to, rate (a header row is optional),
+// a JSON file with an array of FileRate records, or a directory of such files. Files named
+// after a date (e.g. 2025-01-31.csv) may leave the date empty, it defaults to the file name.
+// Rates are rebased to the requested currency through direct, inverse and cross rates.
+type File struct {
+	Path string
+
+	// rates holds the loaded rates by date
+	rates map[string][]FileRate
+	// mu guards the first load
+	mu sync.Mutex
+}
+
+// NewFile creates a new File provider with the provided configuration.
+func NewFile(config ProviderConfig) (*File, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("file provider requires a path")
+	}
+	return &File{Path: config.Path}, nil
+}
+
+// Name returns the provider name used in the configuration.
+func (p *File) Name() string {
+	return "file"
+}
+
+// Catalogue returns all currencies found in the files.
+func (p *File) Catalogue(ctx context.Context) (Catalogue, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	catalogue := make(Catalogue)
+	for _, records := range p.rates {
+		for _, r := range records {
+			catalogue[r.From] = r.From
+			catalogue[r.To] = r.To
+		}
+	}
+	return catalogue, nil
+}
+
+// FetchRates returns the rates of the currency on the date, or on the newest date for "latest".
+func (p *File) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	if err := p.load(); err != nil {
+		return ApiResponse{}, err
+	}
+
+	if date == "" || date == "latest" {
+		date = ""
+		for d := range p.rates {
+			if d > date {
+				date = d
+			}
+		}
+	}
+
+	records, ok := p.rates[date]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("no rates for %s in %s", date, p.Path)
+	}
+
+	rates := resolveRates(records, strings.ToUpper(currency))
+	if len(rates) == 0 {
+		return ApiResponse{}, fmt.Errorf("no rates for %s on %s in %s", currency, date, p.Path)
+	}
+	return ApiResponse{Date: date, Rates: rates}, nil
+}
+
+// resolveRates returns the value of one base unit in every currency reachable from the base.
+func resolveRates(records []FileRate, base string) map[string]float64 {
+	type edge struct {
+		to   string
+		rate float64
+	}
+	// All direct edges come before any inverse edge, so a quoted rate wins over the
+	// inverse of the opposite quote whatever the order of the records
+	graph := make(map[string][]edge)
+	for _, r := range records {
+		graph[r.From] = append(graph[r.From], edge{to: r.To, rate: r.Rate})
+	}
+	for _, r := range records {
+		graph[r.To] = append(graph[r.To], edge{to: r.From, rate: 1 / r.Rate})
+	}
+
+	// Breadth-first, so direct and inverse rates win over cross rates
+	values := map[string]float64{base: 1}
+	queue := []string{base}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, e := range graph[current] {
+			if _, seen := values[e.to]; seen {
+				continue
+			}
+			values[e.to] = values[current] * e.rate
+			queue = append(queue, e.to)
+		}
+	}
+
+	delete(values, base)
+	return values
+}
+
+// load reads all files once.
+func (p *File) load() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rates != nil {
+		return nil
+	}
+
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return err
+	}
+
+	files := []string{p.Path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(p.Path)
+		if err != nil {
+			return err
+		}
+		files = nil
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && (ext == ".csv" || ext == ".json") {
+				files = append(files, filepath.Join(p.Path, entry.Name()))
+			}
+		}
+	}
+
+	p.rates = make(map[string][]FileRate)
+	for _, file := range files {
+		records, err := readRateFile(file)
+		if err != nil {
+			p.rates = nil
+			return fmt.Errorf("failed to read %s: %v", file, err)
+		}
+
+		// Per-date files may leave the date column empty
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		_, dateErr := time.Parse("2006-01-02", name)
+		for _, r := range records {
+			if r.Date == "" && dateErr == nil {
+				r.Date = name
+			}
+			if _, err := time.Parse("2006-01-02", r.Date); err != nil {
+				p.rates = nil
+				return fmt.Errorf("invalid date %q in %s", r.Date, file)
+			}
+			if r.From == "" || r.To == "" || r.Rate <= 0 {
+				p.rates = nil
+				return fmt.Errorf("invalid rate %s/%s on %s in %s", r.From, r.To, r.Date, file)
+			}
+			r.From = strings.ToUpper(r.From)
+			r.To = strings.ToUpper(r.To)
+			p.rates[r.Date] = append(p.rates[r.Date], r)
+		}
+	}
+	return nil
+}
+
+func readRateFile(file string) ([]FileRate, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		var records []FileRate
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	}
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var records []FileRate
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 64)
+		if err != nil {
+			// The first row may be a header
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, row[3])
+		}
+		records = append(records, FileRate{
+			Date: strings.TrimSpace(row[0]),
+			From: strings.TrimSpace(row[1]),
+			To:   strings.TrimSpace(row[2]),
+			Rate: rate,
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRates(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		records []FileRate
+		base    string
+		want    map[string]float64
+	}{
+		{
+			name:    "direct",
+			records: []FileRate{{From: "USD", To: "EUR", Rate: 0.92}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.92},
+		},
+		{
+			name:    "inverse",
+			records: []FileRate{{From: "EUR", To: "USD", Rate: 1.25}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.8},
+		},
+		{
+			name:    "direct wins over an earlier inverse",
+			records: []FileRate{{From: "EUR", To: "USD", Rate: 1.1}, {From: "USD", To: "EUR", Rate: 0.92}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.92},
+		},
+		{
+			name:    "direct wins over a later inverse",
+			records: []FileRate{{From: "USD", To: "EUR", Rate: 0.92}, {From: "EUR", To: "USD", Rate: 1.1}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.92},
+		},
+		{
+			name:    "cross",
+			records: []FileRate{{From: "USD", To: "EUR", Rate: 0.8}, {From: "EUR", To: "GBP", Rate: 0.5}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.8, "GBP": 0.4},
+		},
+		{
+			name:    "inverse wins over cross",
+			records: []FileRate{{From: "USD", To: "EUR", Rate: 0.8}, {From: "EUR", To: "GBP", Rate: 0.5}, {From: "GBP", To: "USD", Rate: 2}},
+			base:    "USD",
+			want:    map[string]float64{"EUR": 0.8, "GBP": 0.5},
+		},
+		{
+			name:    "unreachable",
+			records: []FileRate{{From: "EUR", To: "GBP", Rate: 0.85}},
+			base:    "USD",
+			want:    map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		got := resolveRates(tt.records, tt.base)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for to, want := range tt.want {
+			if math.Abs(got[to]-want) > 1e-12 {
+				t.Errorf("%s: %s/%s = %v, want %v", tt.name, tt.base, to, got[to], want)
+			}
+		}
+	}
+}
+
+func TestFileFetchRates(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"rates.csv":       "date,from,to,rate\n2025-01-02,EUR,USD,1.1\n2025-01-02,usd,eur,0.92\n",
+		"2025-01-03.json": `[{"from": "EUR", "to": "USD", "rate": 1.2}]`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider, err := NewFile(ProviderConfig{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date     string
+		wantDate string
+		want     float64
+	}{
+		{"2025-01-02", "2025-01-02", 0.92},
+		{"latest", "2025-01-03", 1 / 1.2},
+	}
+	for _, tt := range tests {
+		resp, err := provider.FetchRates(context.Background(), "usd", tt.date)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.date, err)
+		}
+		if resp.Date != tt.wantDate || math.Abs(resp.Rates["EUR"]-tt.want) > 1e-12 {
+			t.Errorf("%s: got %s %v, want %s EUR %v", tt.date, resp.Date, resp.Rates, tt.wantDate, tt.want)
+		}
+	}
+
+	if _, err := provider.FetchRates(context.Background(), "usd", "2025-01-04"); err == nil {
+		t.Errorf("2025-01-04: expected an error for a date without rates")
+	}
+}
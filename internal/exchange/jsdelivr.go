@@ -0,0 +1,167 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// JsDelivr is the provider for the fawazahmed0 currency API served from jsDelivr.
+type JsDelivr struct {
+	Config ApiConfig
+
+	// catalogue is loaded once per run
+	catalogue Catalogue
+	mu        sync.Mutex
+}
+
+// NewJsDelivr creates a new JsDelivr provider with the provided configuration.
+func NewJsDelivr(config ApiConfig) *JsDelivr {
+	return &JsDelivr{Config: config}
+}
+
+// Name returns the provider name used in the configuration.
+func (p *JsDelivr) Name() string {
+	return p.Config.Name
+}
+
+// Catalogue returns the currencies supported by the provider.
+func (p *JsDelivr) Catalogue(ctx context.Context) (Catalogue, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.catalogue == nil {
+		catalogue, err := LoadCatalogue(ctx, p.Config)
+		if err != nil {
+			return nil, err
+		}
+		p.catalogue = catalogue
+	}
+	return p.catalogue, nil
+}
+
+// FetchRates returns the rates of the currency against all other currencies on the date.
+func (p *JsDelivr) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+
+	currency = strings.ToLower(currency)
+
+	if date == "" {
+		date = "latest"
+	}
+
+	body, source, err := p.fetchSnapshot(ctx, currency, date)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	resp, err := parseRates(body, currency)
+	resp.Source = source
+	return resp, err
+}
+
+// fetchSnapshot returns the raw rates document for the currency and date, and the URL it was
+// downloaded from or "cache". Dated snapshots never change, so they are served from the cache when possible.
+func (p *JsDelivr) fetchSnapshot(ctx context.Context, currency string, date string) ([]byte, string, error) {
+
+	cacheFile := p.Config.snapshotCacheFile(currency, date)
+	if cacheFile != "" {
+		if body, err := os.ReadFile(cacheFile); err == nil {
+			return body, "cache", nil
+		}
+	}
+
+	slog.Info("Fetching rates", "provider", p.Name(), "base", currency, "date", date)
+
+	source := p.Config.GetURL(date, currency, "currencies")
+	body, err := p.get(ctx, source)
+	if err != nil && p.Config.FallbackURL != "" && ctx.Err() == nil {
+		slog.Warn("Fetching rates from fallback", "provider", p.Name(), "base", currency, "date", date, "error", err)
+		source = p.Config.GetFallbackURL(date, currency, "currencies")
+		body, err = p.get(ctx, source)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cacheFile != "" {
+		if _, err := parseRates(body, currency); err == nil {
+			if err := os.MkdirAll(filepath.Dir(cacheFile), 0o755); err == nil {
+				err = os.WriteFile(cacheFile, body, 0o644)
+				if err != nil {
+					slog.Warn("Failed to cache rates", "provider", p.Name(), "base", currency, "date", date, "error", err)
+				}
+			}
+		}
+	}
+
+	return body, source, nil
+}
+
+// get downloads a rates document.
+func (p *JsDelivr) get(ctx context.Context, url string) ([]byte, error) {
+	return download(ctx, p.Config.HTTPClient, url, "", 0, p.Config.TimeoutSeconds)
+}
+
+// parseRates extracts the date and the rates of the currency from a rates document.
+func parseRates(body []byte, currency string) (ApiResponse, error) {
+
+	var rawJson map[string]interface{}
+	err := json.Unmarshal(body, &rawJson)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	// Extract date and rates
+	// Safely extract "date" as string
+	rawDate, ok := rawJson["date"]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("missing 'date' field in API response")
+	}
+	date, ok := rawDate.(string)
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("'date' field is not a string in API response")
+	}
+
+	// Safely extract rates map
+	rawRates, ok := rawJson[currency]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("missing '%s' field in API response", currency)
+	}
+	ratesMap, ok := rawRates.(map[string]any)
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("'%s' field is not a map in API response", currency)
+	}
+	var rates = make(map[string]float64)
+	for key, value := range ratesMap {
+		floatVal, ok := value.(float64)
+		if !ok {
+			return ApiResponse{}, fmt.Errorf("rate for '%s' is not a float64 in API response", key)
+		}
+		rates[key] = floatVal
+	}
+
+	return ApiResponse{
+		Date:  date,
+		Rates: rates,
+	}, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CurrencyMapping maps a Firefly III currency code to the code used by a rate provider.
+type CurrencyMapping struct {
+	// Firefly is the currency code as it is configured in Firefly III (e.g. "GOLD").
+	Firefly string `mapstructure:"firefly"`
+	// Provider is the name of the provider the mapping applies to. Empty means all providers.
+	Provider string `mapstructure:"provider"`
+	// Code is the currency code used by the provider (e.g. "xau").
+	Code string `mapstructure:"code"`
+	// Multiplier is the number of Firefly units in one provider unit (e.g. 31.1034768 grams in a troy ounce).
+	// Zero means 1.
+	Multiplier float64 `mapstructure:"multiplier"`
+}
+
+// CurrencyMap translates currency codes between Firefly III and a single provider.
+type CurrencyMap struct {
+	byFirefly map[string]CurrencyMapping
+	byCode    map[string][]CurrencyMapping
+}
+
+// NewCurrencyMap builds a CurrencyMap for the given provider from the configured mappings.
+//
+// Parameters:
+//   - mappings: the configured mappings; entries for other providers are ignored.
+//   - provider: the name of the provider (e.g. "jsdelivr").
+//
+// Returns:
+//   - A CurrencyMap and an error if the mappings are inconsistent.
+func NewCurrencyMap(mappings []CurrencyMapping, provider string) (CurrencyMap, error) {
+
+	cm := CurrencyMap{
+		byFirefly: make(map[string]CurrencyMapping),
+		byCode:    make(map[string][]CurrencyMapping),
+	}
+
+	for _, m := range mappings {
+		if m.Provider != "" && !strings.EqualFold(m.Provider, provider) {
+			continue
+		}
+		if m.Firefly == "" || m.Code == "" {
+			return CurrencyMap{}, fmt.Errorf("currency mapping requires both 'firefly' and 'code' fields")
+		}
+		if m.Multiplier < 0 {
+			return CurrencyMap{}, fmt.Errorf("currency mapping for %s has a negative multiplier", m.Firefly)
+		}
+		if m.Multiplier == 0 {
+			m.Multiplier = 1
+		}
+
+		key := strings.ToUpper(m.Firefly)
+		// A provider-specific mapping wins over a generic one
+		if prev, ok := cm.byFirefly[key]; ok && (prev.Provider != "" || m.Provider == "") {
+			continue
+		}
+		cm.byFirefly[key] = m
+	}
+
+	for _, m := range cm.byFirefly {
+		code := strings.ToUpper(m.Code)
+		cm.byCode[code] = append(cm.byCode[code], m)
+	}
+
+	return cm, nil
+}
+
+// Currency returns the Currency for a configured code, keeping the exact Firefly spelling of mapped codes.
+func (cm CurrencyMap) Currency(code string) Currency {
+	if m, ok := cm.byFirefly[strings.ToUpper(code)]; ok {
+		return Currency{Code: m.Firefly}
+	}
+	return NewCurrency(code)
+}
+
+// ProviderCode returns the code the provider uses for the given currency.
+func (cm CurrencyMap) ProviderCode(c Currency) string {
+	if m, ok := cm.byFirefly[strings.ToUpper(c.Code)]; ok {
+		return m.Code
+	}
+	return c.Code
+}
+
+// Multiplier returns the number of Firefly units in one provider unit of the given currency.
+func (cm CurrencyMap) Multiplier(c Currency) float64 {
+	if m, ok := cm.byFirefly[strings.ToUpper(c.Code)]; ok {
+		return m.Multiplier
+	}
+	return 1
+}
+
+// FireflyCurrencies returns the currencies a provider code stands for.
+// A code without mappings stands for the currency of the same name.
+func (cm CurrencyMap) FireflyCurrencies(code string) []Currency {
+	mapped := cm.byCode[strings.ToUpper(code)]
+
+	var currencies []Currency
+	// Skip the plain code when it is mapped to another provider code
+	if m, ok := cm.byFirefly[strings.ToUpper(code)]; !ok || strings.EqualFold(m.Code, code) {
+		currencies = append(currencies, NewCurrency(code))
+	}
+	for _, m := range mapped {
+		if strings.EqualFold(m.Firefly, code) {
+			continue
+		}
+		currencies = append(currencies, Currency{Code: m.Firefly})
+	}
+	return currencies
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// troyOunce is the number of grams in a troy ounce.
+const troyOunce = 31.1034768
+
+// unitProvider serves the rates of each lower-case provider code in provider units.
+type unitProvider map[string]map[string]float64
+
+func (p unitProvider) Name() string { return "units" }
+
+func (p unitProvider) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+	rates, ok := p[strings.ToLower(currency)]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("unknown currency %s", currency)
+	}
+	return ApiResponse{Date: "2025-01-02", Rates: rates}, nil
+}
+
+func (p unitProvider) Catalogue(ctx context.Context) (Catalogue, error) {
+	catalogue := Catalogue{}
+	for code := range p {
+		catalogue[strings.ToUpper(code)] = code
+	}
+	return catalogue, nil
+}
+
+func TestNewCurrencyMap(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		mappings []CurrencyMapping
+		code     string
+		want     string
+		valid    bool
+	}{
+		{"unmapped", nil, "USD", "USD", true},
+		{"generic", []CurrencyMapping{{Firefly: "GOLD", Code: "xau"}}, "GOLD", "xau", true},
+		{"other provider", []CurrencyMapping{{Firefly: "GOLD", Provider: "ecb", Code: "xau"}}, "GOLD", "GOLD", true},
+		{"provider-specific wins", []CurrencyMapping{{Firefly: "GOLD", Provider: "units", Code: "xau"}, {Firefly: "GOLD", Code: "gold"}}, "GOLD", "xau", true},
+		{"missing code", []CurrencyMapping{{Firefly: "GOLD"}}, "", "", false},
+		{"negative multiplier", []CurrencyMapping{{Firefly: "GOLD", Code: "xau", Multiplier: -1}}, "", "", false},
+	}
+	for _, tt := range tests {
+		cm, err := NewCurrencyMap(tt.mappings, "units")
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: NewCurrencyMap() = %v, want valid %v", tt.name, err, tt.valid)
+			continue
+		}
+		if !tt.valid {
+			continue
+		}
+		if got := cm.ProviderCode(cm.Currency(tt.code)); got != tt.want {
+			t.Errorf("%s: ProviderCode(%s) = %s, want %s", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyMapMultiplier(t *testing.T) {
+
+	cm, err := NewCurrencyMap([]CurrencyMapping{
+		{Firefly: "GOLD", Code: "xau", Multiplier: troyOunce},
+		{Firefly: "XAU", Code: "xau"},
+		{Firefly: "KOPECK", Code: "rub", Multiplier: 100},
+	}, "units")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code       string
+		multiplier float64
+	}{
+		{"GOLD", troyOunce},
+		{"gold", troyOunce},
+		{"XAU", 1},
+		{"KOPECK", 100},
+		{"USD", 1},
+	}
+	for _, tt := range tests {
+		if got := cm.Multiplier(NewCurrency(tt.code)); got != tt.multiplier {
+			t.Errorf("Multiplier(%s) = %v, want %v", tt.code, got, tt.multiplier)
+		}
+	}
+
+	// One provider code can stand for several Firefly III currencies
+	var codes []string
+	for _, c := range cm.FireflyCurrencies("xau") {
+		codes = append(codes, c.Code)
+	}
+	sort.Strings(codes)
+	if strings.Join(codes, ",") != "GOLD,XAU" {
+		t.Errorf("FireflyCurrencies(xau) = %v, want XAU and GOLD", codes)
+	}
+}
+
+func TestNewApiMultiplier(t *testing.T) {
+
+	// One troy ounce of gold is worth 2000 USD
+	provider := unitProvider{
+		"xau": {"usd": 2000},
+		"usd": {"xau": 1.0 / 2000},
+	}
+	mappings := []CurrencyMapping{{Firefly: "GOLD", Code: "xau", Multiplier: troyOunce}}
+
+	api, err := NewApi(context.Background(), provider, []string{"GOLD", "USD"}, "latest", mappings, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		// Rates are per gram, the Firefly III unit
+		{"GOLD", "USD", 2000 / troyOunce},
+		{"USD", "GOLD", troyOunce / 2000},
+	}
+	for _, tt := range tests {
+		rate, err := api.GetRate(tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(rate.Value-tt.want) > 1e-12 {
+			t.Errorf("%s/%s = %v, want %v", tt.from, tt.to, rate.Value, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultPipSize is the size of one pip in units of the target currency.
+	DefaultPipSize = 0.0001
+	// JPYPipSize is the size of one pip when the target currency is the Japanese yen.
+	JPYPipSize = 0.01
+)
+
+const (
+	// SideBuy marks up the rate: one From costs more To.
+	SideBuy = "buy"
+	// SideSell marks down the rate: one From yields less To.
+	SideSell = "sell"
+)
+
+// Markup is a spread or fee applied on top of the provider rate for From/To.
+// One of From or To may be empty to match any currency, but not both: the direction
+// of the markup would then depend on the order of the currencies.
+type Markup struct {
+	From    string  `mapstructure:"from"`
+	To      string  `mapstructure:"to"`
+	Percent float64 `mapstructure:"percent"`
+	Pips    float64 `mapstructure:"pips"`
+	// PipSize is the size of one pip in units of To. Zero means JPYPipSize for the yen and
+	// DefaultPipSize otherwise; set it for other currencies quoted with few decimals (e.g. KGS).
+	PipSize float64 `mapstructure:"pip_size"`
+	// Side is SideBuy (default) or SideSell.
+	Side string `mapstructure:"side"`
+}
+
+// Validate checks that the markup is consistent.
+func (m Markup) Validate() error {
+	if m.From == "" && m.To == "" {
+		return fmt.Errorf("markup requires 'from' or 'to', e.g. 'to' set to the currency you pay in")
+	}
+	switch strings.ToLower(m.Side) {
+	case "", SideBuy, SideSell:
+	default:
+		return fmt.Errorf("markup %s/%s has an invalid side %q, expected %q or %q", m.From, m.To, m.Side, SideBuy, SideSell)
+	}
+	if m.Percent < 0 || m.Pips < 0 {
+		return fmt.Errorf("markup %s/%s must not be negative, use side %q instead", m.From, m.To, SideSell)
+	}
+	if m.PipSize < 0 {
+		return fmt.Errorf("markup %s/%s has a negative pip_size", m.From, m.To)
+	}
+	if m.PipSize > 0 && m.To == "" {
+		return fmt.Errorf("markup %s/%s sets pip_size without 'to', the currency the pip is measured in", m.From, m.To)
+	}
+	return nil
+}
+
+// pipSize returns the size of one pip of the markup in units of the target currency.
+func (m Markup) pipSize(to Currency) float64 {
+	if m.PipSize > 0 {
+		return m.PipSize
+	}
+	if to.GetCode() == "JPY" {
+		return JPYPipSize
+	}
+	return DefaultPipSize
+}
+
+// Apply returns the rate of one From in to with the markup applied.
+func (m Markup) Apply(value float64, to Currency) float64 {
+	pips := m.Pips * m.pipSize(to)
+	if strings.ToLower(m.Side) == SideSell {
+		return value*(1-m.Percent/100) - pips
+	}
+	return value*(1+m.Percent/100) + pips
+}
+
+// specificity ranks a markup matching from/to, or returns -1 if it does not match.
+func (m Markup) specificity(mapping CurrencyMap, from Currency, to Currency) int {
+	score := 0
+	if m.From != "" {
+		if mapping.Currency(m.From) != from {
+			return -1
+		}
+		score++
+	}
+	if m.To != "" {
+		if mapping.Currency(m.To) != to {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// ApplyMarkups applies the markups to the rates between the requested currencies.
+// For every pair the most specific markup wins, and the inverse rate is set to the
+// reciprocal of the adjusted rate so both directions stay consistent. Overridden
+// rates are left untouched.
+//
+// Parameters:
+//   - markups: the configured markups.
+//
+// Returns:
+//   - An error if a markup is invalid.
+func (api *Api) ApplyMarkups(markups []Markup) error {
+
+	for _, m := range markups {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i, a := range api.Currencies {
+		for _, b := range api.Currencies[i+1:] {
+			if a == b {
+				continue
+			}
+
+			// Pick the most specific markup in either direction
+			best, bestScore := Markup{}, -1
+			from, to := a, b
+			for _, m := range markups {
+				if score := m.specificity(api.Mapping, a, b); score > bestScore {
+					best, bestScore, from, to = m, score, a, b
+				}
+				if score := m.specificity(api.Mapping, b, a); score > bestScore {
+					best, bestScore, from, to = m, score, b, a
+				}
+			}
+			if bestScore < 0 {
+				continue
+			}
+
+			rate, err := api.GetRate(from.String(), to.String())
+			if err != nil || rate.Override {
+				continue
+			}
+
+			adjusted := best.Apply(rate.Value, to)
+			if adjusted <= 0 {
+				return fmt.Errorf("markup for %s/%s results in a non-positive rate", from, to)
+			}
+
+			api.setRate(Rate{Date: rate.Date, Pair: rate.Pair, Value: adjusted, Raw: rate.Value, Adjusted: true, Source: rate.Source, Days: rate.Days})
+
+			inverse := Pair{From: to, To: from}
+			raw := 1 / rate.Value
+			source := rate.Source
+			if r, err := api.GetRate(to.String(), from.String()); err == nil {
+				raw = r.Value
+				source = r.Source
+			}
+			api.setRate(Rate{Date: rate.Date, Pair: inverse, Value: 1 / adjusted, Raw: raw, Adjusted: true, Source: source, Days: rate.Days})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMarkupValidate(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		markup Markup
+		valid  bool
+	}{
+		{"to only", Markup{To: "KGS", Percent: 1.75}, true},
+		{"from only", Markup{From: "EUR", Percent: 1}, true},
+		{"pair with pip size", Markup{From: "USD", To: "KGS", Pips: 5, PipSize: 0.01}, true},
+		{"sell side", Markup{To: "KGS", Percent: 1, Side: "sell"}, true},
+		{"unqualified", Markup{Percent: 1.75}, false},
+		{"invalid side", Markup{To: "KGS", Side: "both"}, false},
+		{"negative percent", Markup{To: "KGS", Percent: -1}, false},
+		{"negative pips", Markup{To: "KGS", Pips: -1}, false},
+		{"pip size without to", Markup{From: "USD", Pips: 5, PipSize: 0.01}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.markup.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestApplyMarkups(t *testing.T) {
+
+	rates := map[string]float64{
+		"USD/KGS": 87.5,
+		"KGS/USD": 1 / 87.5,
+		"USD/JPY": 150,
+		"JPY/USD": 1 / 150.0,
+		"EUR/USD": 1.1,
+		"USD/EUR": 1 / 1.1,
+	}
+
+	tests := []struct {
+		name       string
+		currencies []string
+		markups    []Markup
+		want       map[string]float64
+	}{
+		{
+			name:       "to only, home currency first",
+			currencies: []string{"KGS", "USD"},
+			markups:    []Markup{{To: "KGS", Percent: 2}},
+			want:       map[string]float64{"USD/KGS": 87.5 * 1.02, "KGS/USD": 1 / (87.5 * 1.02)},
+		},
+		{
+			name:       "to only, home currency last",
+			currencies: []string{"USD", "KGS"},
+			markups:    []Markup{{To: "KGS", Percent: 2}},
+			want:       map[string]float64{"USD/KGS": 87.5 * 1.02, "KGS/USD": 1 / (87.5 * 1.02)},
+		},
+		{
+			name:       "sell side",
+			currencies: []string{"USD", "KGS"},
+			markups:    []Markup{{From: "USD", To: "KGS", Percent: 2, Side: "sell"}},
+			want:       map[string]float64{"USD/KGS": 87.5 * 0.98},
+		},
+		{
+			name:       "pips in yen",
+			currencies: []string{"USD", "JPY"},
+			markups:    []Markup{{To: "JPY", Pips: 10}},
+			want:       map[string]float64{"USD/JPY": 150.1},
+		},
+		{
+			name:       "pips with pip size",
+			currencies: []string{"USD", "KGS"},
+			markups:    []Markup{{From: "USD", To: "KGS", Pips: 10, PipSize: 0.01}},
+			want:       map[string]float64{"USD/KGS": 87.6},
+		},
+		{
+			name:       "default pip size",
+			currencies: []string{"EUR", "USD"},
+			markups:    []Markup{{From: "EUR", To: "USD", Pips: 10}},
+			want:       map[string]float64{"EUR/USD": 1.101},
+		},
+		{
+			name:       "most specific wins",
+			currencies: []string{"USD", "KGS"},
+			markups:    []Markup{{To: "KGS", Percent: 2}, {From: "USD", To: "KGS", Percent: 1}},
+			want:       map[string]float64{"USD/KGS": 87.5 * 1.01},
+		},
+		{
+			name:       "unmatched pair",
+			currencies: []string{"EUR", "USD"},
+			markups:    []Markup{{To: "KGS", Percent: 2}},
+			want:       map[string]float64{"EUR/USD": 1.1},
+		},
+	}
+	for _, tt := range tests {
+		api := testApi("2025-01-02", tt.currencies, rates)
+		if err := api.ApplyMarkups(tt.markups); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for pair, want := range tt.want {
+			rate, err := api.GetRate(pair[:3], pair[4:])
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if math.Abs(rate.Value-want) > 1e-9 {
+				t.Errorf("%s: %s = %v, want %v", tt.name, pair, rate.Value, want)
+			}
+			if rate.Adjusted && math.Abs(rate.Raw-rates[pair]) > 1e-12 {
+				t.Errorf("%s: %s raw = %v, want %v", tt.name, pair, rate.Raw, rates[pair])
+			}
+		}
+	}
+}
+
+func TestApplyMarkupsSkipsOverrides(t *testing.T) {
+
+	api := testApi("2025-01-02", []string{"USD", "KGS"}, map[string]float64{"USD/KGS": 87.5})
+	if err := api.ApplyOverrides([]Override{{From: "USD", To: "KGS", Rate: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.ApplyMarkups([]Markup{{To: "KGS", Percent: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	rate, err := api.GetRate("USD", "KGS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.Value != 80 || rate.Adjusted {
+		t.Errorf("USD/KGS = %+v, want the override 80 unadjusted", rate)
+	}
+}
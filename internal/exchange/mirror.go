@@ -0,0 +1,153 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MirrorPath returns the path of a rates snapshot relative to a mirror root.
+// It follows the URL layout of the API: {date}/v1/currencies/{currency}.min.json.
+func MirrorPath(date string, currency string) string {
+	return filepath.Join(date, "v1", "currencies", strings.ToLower(currency)+".min.json")
+}
+
+// MirrorCataloguePath returns the path of the currency catalogue relative to a mirror root.
+func MirrorCataloguePath(date string) string {
+	return filepath.Join(date, "v1", "currencies.min.json")
+}
+
+// Mirror copies the rate snapshots of the currencies on the dates, and the currency catalogue,
+// into dir using the URL layout of the API. Dated files that already exist are skipped. The
+// "latest" date is always refreshed and also stored under the date it resolves to.
+//
+// Parameters:
+//   - ctx: the context that cancels the downloads.
+//   - dir: the mirror root directory.
+//   - currencies: the provider codes of the currencies to mirror.
+//   - dates: the dates (YYYY-MM-DD or "latest") to mirror.
+//
+// Returns:
+//   - The number of files written and an error if a download fails.
+func (p *JsDelivr) Mirror(ctx context.Context, dir string, currencies []string, dates []string) (int, error) {
+
+	written := 0
+	for _, date := range dates {
+		catalogueFile := filepath.Join(dir, MirrorCataloguePath(date))
+		if date == "latest" || !exists(catalogueFile) {
+			body, err := p.get(ctx, p.Config.GetCatalogueURL(date))
+			if err != nil {
+				return written, fmt.Errorf("failed to mirror currency catalogue on %s: %v", date, err)
+			}
+			if err := writeFile(catalogueFile, body); err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		for _, currency := range currencies {
+			currency = strings.ToLower(currency)
+
+			file := filepath.Join(dir, MirrorPath(date, currency))
+			if date != "latest" && exists(file) {
+				continue
+			}
+
+			body, _, err := p.fetchSnapshot(ctx, currency, date)
+			if err != nil {
+				return written, fmt.Errorf("failed to mirror %s on %s: %v", currency, date, err)
+			}
+			resp, err := parseRates(body, currency)
+			if err != nil {
+				return written, fmt.Errorf("failed to mirror %s on %s: %v", currency, date, err)
+			}
+
+			files := []string{file}
+			if date == "latest" {
+				files = append(files, filepath.Join(dir, MirrorPath(resp.Date, currency)))
+			}
+			for _, f := range files {
+				if err := writeFile(f, body); err != nil {
+					return written, err
+				}
+				written++
+			}
+			slog.Info("Mirrored rates", "base", currency, "date", resp.Date)
+		}
+	}
+
+	return written, nil
+}
+
+// MirrorHandler serves a mirror directory with the URL layout of the API.
+// Directory listings are not served.
+func MirrorHandler(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/") || !strings.HasSuffix(r.URL.Path, ".json") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		files.ServeHTTP(w, r)
+	})
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+// writeFile writes the file next to its path and renames it into place, so an interrupted
+// sync never leaves a truncated file that a resumed sync would skip as mirrored.
+// The temporary name does not end with .json, MirrorHandler never serves it.
+func writeFile(file string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
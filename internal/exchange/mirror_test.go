@@ -0,0 +1,182 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mirrorTestServer serves the jsdelivr layout for 2025-01-02, "latest" resolving to it, and
+// records the requested paths.
+func mirrorTestServer(t *testing.T) (*httptest.Server, func() []string) {
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+
+		date, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if date != "2025-01-02" && date != "latest" {
+			http.NotFound(w, r)
+			return
+		}
+		switch path {
+		case "v1/currencies.min.json":
+			w.Write([]byte(`{"eur": "Euro", "usd": "US Dollar"}`))
+		case "v1/currencies/usd.min.json":
+			w.Write([]byte(`{"date": "2025-01-02", "usd": {"eur": 0.96}}`))
+		case "v1/currencies/eur.min.json":
+			w.Write([]byte(`{"date": "2025-01-02", "eur": {"usd": 1.04}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		result := append([]string(nil), requests...)
+		requests = nil
+		sort.Strings(result)
+		return result
+	}
+}
+
+func TestMirrorResume(t *testing.T) {
+
+	server, requests := mirrorTestServer(t)
+	config := GetApiConfig()
+	config.URL = server.URL + "/%s/v1/%s/%s.min.json"
+	config.CatalogueURL = server.URL + "/%s/v1/currencies.min.json"
+	config.FallbackURL = ""
+	config.CacheDir = ""
+	provider := NewJsDelivr(config)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// An interrupted run mirrored the catalogue and USD, and left a temporary file behind
+	written, err := provider.Mirror(ctx, dir, []string{"USD"}, []string{"2025-01-02"})
+	if err != nil || written != 2 {
+		t.Fatalf("first run wrote %d files, %v; want 2", written, err)
+	}
+	leftover := filepath.Join(dir, "2025-01-02", "v1", "currencies", ".eur.min.json.123")
+	if err := os.WriteFile(leftover, []byte(`{"date": "2025-01`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	requests()
+
+	// The resumed run only downloads what is missing
+	written, err = provider.Mirror(ctx, dir, []string{"USD", "EUR"}, []string{"2025-01-02"})
+	if err != nil || written != 1 {
+		t.Fatalf("resumed run wrote %d files, %v; want 1", written, err)
+	}
+	if got := requests(); !slices.Equal(got, []string{"/2025-01-02/v1/currencies/eur.min.json"}) {
+		t.Errorf("resumed run requested %v, want only EUR", got)
+	}
+
+	// "latest" is always refreshed and stored under its date too
+	written, err = provider.Mirror(ctx, dir, []string{"EUR"}, []string{"latest"})
+	if err != nil || written != 3 {
+		t.Fatalf("latest run wrote %d files, %v; want 3", written, err)
+	}
+	body, err := os.ReadFile(filepath.Join(dir, MirrorPath("latest", "eur")))
+	if err != nil || !strings.Contains(string(body), `"usd": 1.04`) {
+		t.Errorf("latest EUR = %s, %v", body, err)
+	}
+
+	// No temporary files of the mirror itself are left
+	var temporary []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && strings.HasPrefix(d.Name(), ".") && path != leftover {
+			temporary = append(temporary, path)
+		}
+		return nil
+	})
+	if len(temporary) > 0 {
+		t.Errorf("temporary files left: %v", temporary)
+	}
+
+	// A failed download stops the run with what was written so far
+	written, err = provider.Mirror(ctx, dir, []string{"GBP"}, []string{"2025-01-02"})
+	if err == nil || written != 0 || !strings.Contains(err.Error(), "gbp") {
+		t.Errorf("failed run wrote %d files, %v; want an error for gbp", written, err)
+	}
+}
+
+func TestMirrorHandler(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, file := range []string{MirrorPath("2025-01-02", "usd"), MirrorCataloguePath("2025-01-02"), "2025-01-02/v1/notes.txt", "2025-01-02/v1/currencies/.usd.min.json.42"} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{"date": "2025-01-02"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := httptest.NewServer(MirrorHandler(dir))
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/2025-01-02/v1/currencies/usd.min.json", http.StatusOK},
+		{http.MethodHead, "/2025-01-02/v1/currencies.min.json", http.StatusOK},
+		{http.MethodGet, "/2025-01-02/v1/currencies/eur.min.json", http.StatusNotFound},
+		// Directories are not listed, with or without a trailing slash
+		{http.MethodGet, "/2025-01-02/v1/currencies/", http.StatusNotFound},
+		{http.MethodGet, "/2025-01-02/v1/currencies", http.StatusNotFound},
+		{http.MethodGet, "/", http.StatusNotFound},
+		// Only JSON files are served, not other files or the temporary files of a sync
+		{http.MethodGet, "/2025-01-02/v1/notes.txt", http.StatusNotFound},
+		{http.MethodGet, "/2025-01-02/v1/currencies/.usd.min.json.42", http.StatusNotFound},
+		{http.MethodPost, "/2025-01-02/v1/currencies/usd.min.json", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK && resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: content type %q", tt.method, tt.path, resp.Header.Get("Content-Type"))
+		}
+	}
+}
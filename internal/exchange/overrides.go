@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"fmt"
+	"time"
+)
+
+// Override replaces or adds a rate that is not taken from the provider.
+//
+// An override with To sets a fixed rate for the pair From/To (and its inverse).
+// An override with Peg pegs From to another currency: 1 From = Rate Peg, and
+// the rates of From against every other currency are derived from the rates of Peg.
+type Override struct {
+	// From is the currency the rate is quoted for.
+	From string `mapstructure:"from"`
+	// To is the target currency of a fixed rate.
+	To string `mapstructure:"to"`
+	// Peg is the currency From is pegged to.
+	Peg string `mapstructure:"peg"`
+	// Rate is the value of one From in To or Peg.
+	Rate float64 `mapstructure:"rate"`
+	// Start is the first date (YYYY-MM-DD) the override applies to. Empty means no lower bound.
+	Start string `mapstructure:"start"`
+	// End is the last date (YYYY-MM-DD) the override applies to. Empty means no upper bound.
+	End string `mapstructure:"end"`
+}
+
+// Validate checks that the override is complete and consistent.
+func (o Override) Validate() error {
+	if o.From == "" {
+		return fmt.Errorf("override requires a 'from' currency")
+	}
+	if (o.To == "") == (o.Peg == "") {
+		return fmt.Errorf("override for %s requires exactly one of 'to' or 'peg'", o.From)
+	}
+	if o.Rate <= 0 {
+		return fmt.Errorf("override for %s requires a positive 'rate'", o.From)
+	}
+	for _, date := range []string{o.Start, o.End} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("override for %s has an invalid date %q", o.From, date)
+		}
+	}
+	if o.Start != "" && o.End != "" && o.End < o.Start {
+		return fmt.Errorf("override for %s ends before it starts", o.From)
+	}
+	return nil
+}
+
+// AppliesTo reports whether the override is in effect on the given date (YYYY-MM-DD).
+func (o Override) AppliesTo(date string) bool {
+	return (o.Start == "" || date >= o.Start) && (o.End == "" || date <= o.End)
+}
+
+// IsPermanentPeg reports whether From is always derived from its peg and never fetched from the provider.
+func (o Override) IsPermanentPeg() bool {
+	return o.Peg != "" && o.Start == "" && o.End == ""
+}
+
+// ApplyOverrides merges the overrides into the fetched rates.
+// Fixed rates are applied after pegs, so a fixed rate wins over a rate derived from a peg.
+//
+// Parameters:
+//   - overrides: the configured overrides.
+//
+// Returns:
+//   - An error if an override is invalid.
+func (api *Api) ApplyOverrides(overrides []Override) error {
+
+	for _, o := range overrides {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+
+	date := api.Date
+	for _, o := range overrides {
+		if o.Peg == "" || !o.AppliesTo(date) {
+			continue
+		}
+
+		from := api.Mapping.Currency(o.From)
+		peg := api.Mapping.Currency(o.Peg)
+		api.setRate(Rate{Date: date, Pair: Pair{From: from, To: peg}, Value: o.Rate, Override: true})
+		api.setRate(Rate{Date: date, Pair: Pair{From: peg, To: from}, Value: 1 / o.Rate, Override: true})
+
+		for _, currency := range api.Currencies {
+			if currency == from || currency == peg {
+				continue
+			}
+			// Missing rates are reported when the pair is requested
+			pegRate, ok := api.crossRate(peg, currency)
+			if !ok {
+				continue
+			}
+			value := o.Rate * pegRate
+			api.setRate(Rate{Date: date, Pair: Pair{From: from, To: currency}, Value: value, Override: true})
+			api.setRate(Rate{Date: date, Pair: Pair{From: currency, To: from}, Value: 1 / value, Override: true})
+		}
+	}
+
+	for _, o := range overrides {
+		if o.To == "" || !o.AppliesTo(date) {
+			continue
+		}
+
+		from := api.Mapping.Currency(o.From)
+		to := api.Mapping.Currency(o.To)
+		api.setRate(Rate{Date: date, Pair: Pair{From: from, To: to}, Value: o.Rate, Override: true})
+		api.setRate(Rate{Date: date, Pair: Pair{From: to, To: from}, Value: 1 / o.Rate, Override: true})
+	}
+
+	return nil
+}
+
+// crossRate returns the rate from/to, using the inverse rate when the direct one is missing.
+func (api *Api) crossRate(from Currency, to Currency) (float64, bool) {
+	for _, r := range api.Rates {
+		if r.Pair.From == from && r.Pair.To == to {
+			return r.Value, true
+		}
+	}
+	for _, r := range api.Rates {
+		if r.Pair.From == to && r.Pair.To == from && r.Value != 0 {
+			return 1 / r.Value, true
+		}
+	}
+	return 0, false
+}
+
+// setRate replaces the rate for the pair or adds it if missing.
+func (api *Api) setRate(rate Rate) {
+	for i, r := range api.Rates {
+		if r.Pair == rate.Pair {
+			api.Rates[i] = rate
+			return
+		}
+	}
+	api.Rates = append(api.Rates, rate)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"math"
+	"testing"
+)
+
+// testApi returns an Api on the date holding the rates, keyed by "FROM/TO".
+func testApi(date string, currencies []string, rates map[string]float64) *Api {
+	api := &Api{Date: date}
+	for _, c := range currencies {
+		api.Currencies = append(api.Currencies, NewCurrency(c))
+	}
+	for pair, value := range rates {
+		api.Rates = append(api.Rates, Rate{
+			Date:  date,
+			Pair:  Pair{From: NewCurrency(pair[:3]), To: NewCurrency(pair[4:])},
+			Value: value,
+		})
+	}
+	return api
+}
+
+func TestOverrideValidate(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		override Override
+		valid    bool
+	}{
+		{"fixed rate", Override{From: "USD", To: "KGS", Rate: 87.5}, true},
+		{"peg", Override{From: "AED", Peg: "USD", Rate: 0.2723}, true},
+		{"dated", Override{From: "USD", To: "KGS", Rate: 87.5, Start: "2025-01-01", End: "2025-01-31"}, true},
+		{"no from", Override{To: "KGS", Rate: 87.5}, false},
+		{"to and peg", Override{From: "AED", To: "EUR", Peg: "USD", Rate: 1}, false},
+		{"neither to nor peg", Override{From: "AED", Rate: 1}, false},
+		{"zero rate", Override{From: "USD", To: "KGS"}, false},
+		{"negative rate", Override{From: "USD", To: "KGS", Rate: -1}, false},
+		{"invalid date", Override{From: "USD", To: "KGS", Rate: 1, Start: "2025-02-30"}, false},
+		{"ends before start", Override{From: "USD", To: "KGS", Rate: 1, Start: "2025-02-01", End: "2025-01-01"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.override.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		date      string
+		overrides []Override
+		want      map[string]float64
+	}{
+		{
+			name:      "fixed rate sets both directions",
+			date:      "2025-01-15",
+			overrides: []Override{{From: "USD", To: "KGS", Rate: 80}},
+			want:      map[string]float64{"USD/KGS": 80, "KGS/USD": 1.0 / 80, "USD/EUR": 0.9},
+		},
+		{
+			name:      "outside the date range",
+			date:      "2025-02-15",
+			overrides: []Override{{From: "USD", To: "KGS", Rate: 80, Start: "2025-01-01", End: "2025-01-31"}},
+			want:      map[string]float64{"USD/KGS": 87.5},
+		},
+		{
+			name:      "peg derives cross rates",
+			date:      "2025-01-15",
+			overrides: []Override{{From: "AED", Peg: "USD", Rate: 0.25}},
+			want:      map[string]float64{"AED/USD": 0.25, "USD/AED": 4, "AED/EUR": 0.25 * 0.9, "EUR/AED": 1 / (0.25 * 0.9), "AED/KGS": 0.25 * 87.5},
+		},
+		{
+			name:      "fixed rate wins over peg",
+			date:      "2025-01-15",
+			overrides: []Override{{From: "AED", To: "EUR", Rate: 0.3}, {From: "AED", Peg: "USD", Rate: 0.25}},
+			want:      map[string]float64{"AED/EUR": 0.3, "EUR/AED": 1 / 0.3, "AED/USD": 0.25},
+		},
+	}
+	for _, tt := range tests {
+		api := testApi(tt.date, []string{"USD", "EUR", "KGS", "AED"}, map[string]float64{
+			"USD/EUR": 0.9,
+			"USD/KGS": 87.5,
+		})
+		if err := api.ApplyOverrides(tt.overrides); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for pair, want := range tt.want {
+			rate, err := api.GetRate(pair[:3], pair[4:])
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if math.Abs(rate.Value-want) > 1e-12 {
+				t.Errorf("%s: %s = %v, want %v", tt.name, pair, rate.Value, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultProvider is the provider used when none is configured.
+const DefaultProvider = "jsdelivr"
+
+// ErrNoCatalogue is returned by Provider.Catalogue when the provider cannot list its
+// currencies. The currencies are then not validated before fetching.
+var ErrNoCatalogue = errors.New("provider has no currency catalogue")
+
+// Provider is a source of exchange rates.
+type Provider interface {
+	// Name returns the provider name used in the configuration and in currency mappings.
+	Name() string
+	// FetchRates returns the rates of the currency against all other currencies the provider
+	// knows on the date (YYYY-MM-DD, or "latest"). Currency codes are the provider's codes.
+	// The request is cancelled with ctx.
+	FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error)
+	// Catalogue returns the currencies supported by the provider, or ErrNoCatalogue.
+	Catalogue(ctx context.Context) (Catalogue, error)
+}
+
+// DatedCatalogue is implemented by providers whose currencies change over time, e.g. a central
+// bank that stopped publishing a currency. Dated fetches are validated against the catalogue of the date.
+type DatedCatalogue interface {
+	// CatalogueOn returns the currencies supported on the date (YYYY-MM-DD).
+	CatalogueOn(ctx context.Context, date string) (Catalogue, error)
+}
+
+// CurrencySelector is implemented by providers that need to know all requested currencies
+// before the first FetchRates call, e.g. to pass them to an external source.
+type CurrencySelector interface {
+	// SelectCurrencies receives the provider codes of the requested currencies.
+	SelectCurrencies(codes []string)
+}
+
+// ProviderConfig holds the settings of a provider from the providers section of the configuration.
+// Providers ignore the settings they do not use.
+type ProviderConfig struct {
+	// URL replaces the default base URL of the provider, e.g. to point it at a mirror or a mock.
+	URL string `mapstructure:"url"`
+	// FallbackURL is the base URL tried when URL fails, e.g. a self-hosted mirror.
+	FallbackURL string `mapstructure:"fallback_url"`
+	// TimeoutSeconds specifies the timeout for provider requests in seconds.
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	// Timezone replaces the time zone a central bank publishes its rates in (e.g. "Asia/Bishkek").
+	Timezone string `mapstructure:"timezone"`
+	// Headers are additional HTTP headers sent with every request, e.g. an API key.
+	Headers map[string]string `mapstructure:"headers"`
+	// Coins maps currency codes to coin IDs of a crypto provider (e.g. BTC: bitcoin).
+	Coins map[string]string `mapstructure:"coins"`
+	// TimeOfDay selects which daily crypto price is used for a date: "close" (default) or "open".
+	TimeOfDay string `mapstructure:"time_of_day"`
+	// Command is the command and its arguments run by the exec provider.
+	// The placeholders {base}, {date} and {currencies} are replaced in the arguments.
+	Command []string `mapstructure:"command"`
+	// Path is the CSV or JSON file, or the directory of per-date files, read by the file provider.
+	Path string `mapstructure:"path"`
+	// Holidays lists non-business days (YYYY-MM-DD) without official rates, in addition to weekends.
+	Holidays []string `mapstructure:"holidays"`
+	// HTTPClient is the client used for all requests, shared to reuse connections.
+	// It is set by the caller, not read from the configuration. When nil, http.DefaultClient is used.
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+type providerFactory func(config ProviderConfig) (Provider, error)
+
+var providers = map[string]providerFactory{
+	DefaultProvider: func(config ProviderConfig) (Provider, error) {
+		apiConfig := GetApiConfig()
+		if config.URL != "" {
+			apiConfig.URL = strings.TrimSuffix(config.URL, "/") + "/%s/v1/%s/%s.min.json"
+			apiConfig.CatalogueURL = strings.TrimSuffix(config.URL, "/") + "/%s/v1/currencies.min.json"
+		}
+		if config.FallbackURL != "" {
+			apiConfig.FallbackURL = strings.TrimSuffix(config.FallbackURL, "/") + "/%s/v1/%s/%s.min.json"
+		}
+		if config.TimeoutSeconds > 0 {
+			apiConfig.TimeoutSeconds = config.TimeoutSeconds
+		}
+		apiConfig.HTTPClient = config.HTTPClient
+		return NewJsDelivr(apiConfig), nil
+	},
+	"ecb": func(config ProviderConfig) (Provider, error) {
+		return NewECB(config), nil
+	},
+	"coingecko": func(config ProviderConfig) (Provider, error) {
+		return NewCoinGecko(config)
+	},
+	"exec": func(config ProviderConfig) (Provider, error) {
+		return NewExec(config)
+	},
+	"file": func(config ProviderConfig) (Provider, error) {
+		return NewFile(config)
+	},
+	"nbkr": func(config ProviderConfig) (Provider, error) {
+		return NewCentralBank("nbkr", config)
+	},
+	"nbp": func(config ProviderConfig) (Provider, error) {
+		return NewCentralBank("nbp", config)
+	},
+	"cbr": func(config ProviderConfig) (Provider, error) {
+		return NewCentralBank("cbr", config)
+	},
+}
+
+// NewProvider creates the provider with the given name.
+//
+// Parameters:
+//   - name: the provider name (e.g. "jsdelivr", "ecb" or "nbp"). Empty means DefaultProvider.
+//   - config: the provider settings.
+//
+// Returns:
+//   - The Provider and an error if the name is unknown.
+func NewProvider(name string, config ProviderConfig) (Provider, error) {
+	if name == "" {
+		name = DefaultProvider
+	}
+
+	factory, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, expected one of: %s", name, strings.Join(ProviderNames(), ", "))
+	}
+	return factory(config)
+}
+
+// ProviderNames returns the names of all available providers.
+func ProviderNames() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -26,8 +26,24 @@ type Rate struct {
 	Date  string
 	Pair  Pair
 	Value float64
+	// Override is true when the rate comes from the configured overrides rather than the provider.
+	Override bool
+	// Adjusted is true when a markup has been applied, Raw then holds the rate before the markup.
+	Adjusted bool
+	Raw      float64
+	// Source is where the provider served the rate from, see ApiResponse.Source.
+	Source string
+	// Days is the number of daily rates of a period average, 0 for daily rates.
+	Days int
 }
 
 func (r Rate) String() string {
-	return r.Pair.From.String() + "/" + r.Pair.To.String() + ": " + fmt.Sprintf("%f", r.Value) + " on " + r.Date
+	s := r.Pair.From.String() + "/" + r.Pair.To.String() + ": " + fmt.Sprintf("%f", r.Value) + " on " + r.Date
+	if r.Override {
+		s += " (override)"
+	}
+	if r.Adjusted {
+		s += fmt.Sprintf(" (raw %f)", r.Raw)
+	}
+	return s
 }
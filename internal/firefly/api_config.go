@@ -15,6 +15,8 @@ limitations under the License.
 */
 package firefly
 
+import "net/http"
+
 // ApiConfig holds configuration for the Firefly III API.
 type ApiConfig struct {
 	// ApiKey is the API key used for authentication.
@@ -23,4 +25,7 @@ type ApiConfig struct {
 	ApiUrl string
 	// TimeoutSeconds specifies the timeout for API requests in seconds.
 	TimeoutSeconds int
+	// HTTPClient is the client used for all requests, shared to reuse connections.
+	// When nil, http.DefaultClient is used.
+	HTTPClient *http.Client
 }
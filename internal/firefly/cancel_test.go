@@ -0,0 +1,75 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+func TestRequestCancel(t *testing.T) {
+
+	// The server never answers, only a cancelled context ends the requests
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	api := firefly.NewApi(firefly.ApiConfig{ApiUrl: server.URL, ApiKey: "test", TimeoutSeconds: 30})
+
+	tests := []struct {
+		name    string
+		request func(ctx context.Context) error
+	}{
+		{"post", func(ctx context.Context) error {
+			_, err := api.PostExchangeRatesByDate(ctx, "USD", map[string]float64{"EUR": 0.96}, "2025-01-02")
+			return err
+		}},
+		{"get", func(ctx context.Context) error {
+			_, _, err := api.GetExchangeRate(ctx, "USD", "EUR", "2025-01-02")
+			return err
+		}},
+		{"list", func(ctx context.Context) error {
+			_, err := api.ListExchangeRates(ctx, firefly.ListOptions{})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := tt.request(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: error %v, want context.Canceled", tt.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: returned after %v, want promptly", tt.name, elapsed)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly
+
+import (
+	"context"
+	"fmt"
+)
+
+const CurrenciesTemplate = "%s/currencies"
+
+// Currency is a currency as it is defined in Firefly III.
+type Currency struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Symbol  string `json:"symbol"`
+	Enabled bool   `json:"enabled"`
+}
+
+// GetCurrencies returns all currencies defined in Firefly III, enabled or not.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//
+// Returns:
+//   - A slice of Currency and an error if the operation fails.
+func (api *Api) GetCurrencies(ctx context.Context) ([]Currency, error) {
+
+	resources, err := listAll[Currency](ctx, api, fmt.Sprintf(CurrenciesTemplate, api.Config.ApiUrl), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currencies: %w", err)
+	}
+
+	var currencies []Currency
+	for _, r := range resources {
+		currencies = append(currencies, r.Attributes)
+	}
+	return currencies, nil
+}
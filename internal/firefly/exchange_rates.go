@@ -0,0 +1,359 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const ExchangeRateByIdTemplate = "%s/exchange-rates/%s"
+const ExchangeRateByPairTemplate = "%s/exchange-rates/rates/%s/%s"
+const ExchangeRateByPairDateTemplate = "%s/exchange-rates/rates/%s/%s/%s"
+
+// ExchangeRate is an exchange rate stored in Firefly III: one From is worth Rate To on Date.
+type ExchangeRate struct {
+	// ID is the Firefly III identifier of the rate.
+	ID string
+	// From is the source currency code.
+	From string
+	// To is the target currency code.
+	To string
+	// Date is the date of the rate (YYYY-MM-DD).
+	Date string
+	// Rate is the value of one From in To.
+	Rate float64
+	// CreatedAt and UpdatedAt are the times the rate was stored and last changed.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ExchangeRateAttributes are the attributes of a currency_exchange_rates resource.
+type ExchangeRateAttributes struct {
+	CreatedAt            string `json:"created_at"`
+	UpdatedAt            string `json:"updated_at"`
+	FromCurrencyId       string `json:"from_currency_id"`
+	FromCurrencyName     string `json:"from_currency_name"`
+	FromCurrencyCode     string `json:"from_currency_code"`
+	FromCurrencySymbol   string `json:"from_currency_symbol"`
+	FromCurrencyDecimals int    `json:"from_currency_decimal_places"`
+	ToCurrencyId         string `json:"to_currency_id"`
+	ToCurrencyName       string `json:"to_currency_name"`
+	ToCurrencyCode       string `json:"to_currency_code"`
+	ToCurrencySymbol     string `json:"to_currency_symbol"`
+	ToCurrencyDecimals   int    `json:"to_currency_decimal_places"`
+	Rate                 string `json:"rate"`
+	Date                 string `json:"date"`
+}
+
+// ExchangeRateDocument is the JSON:API document of a single exchange rate.
+type ExchangeRateDocument = document[ExchangeRateAttributes]
+
+// ExchangeRateListDocument is the JSON:API document of a page of exchange rates.
+type ExchangeRateListDocument = listDocument[ExchangeRateAttributes]
+
+// ExchangeRatePage is one page of exchange rates, as Firefly III returned it. Rates and
+// Pagination always agree: Pagination.Count is the number of Rates.
+type ExchangeRatePage struct {
+	Rates      []ExchangeRate
+	Pagination Pagination
+}
+
+// ListOptions filters and pages exchange rate lists.
+type ListOptions struct {
+	// Start and End limit the rates to a date range (YYYY-MM-DD, inclusive); empty leaves that side open.
+	Start string
+	End   string
+	// Page is the page returned by the page methods; 0 means the first page.
+	Page int
+	// Limit is the page size; 0 means the server default.
+	Limit int
+}
+
+func (o ListOptions) query() url.Values {
+	query := url.Values{}
+	if o.Start != "" {
+		query.Set("start", o.Start)
+	}
+	if o.End != "" {
+		query.Set("end", o.End)
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return query
+}
+
+// includes reports whether the date lies within the range of the options.
+// The range is also applied locally, as not every Firefly III version filters by date.
+func (o ListOptions) includes(date string) bool {
+	return (o.Start == "" || date >= o.Start) && (o.End == "" || date <= o.End)
+}
+
+func (a ExchangeRateAttributes) toExchangeRate(id string) (ExchangeRate, error) {
+	rate, err := strconv.ParseFloat(a.Rate, 64)
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("invalid rate %q for %s/%s", a.Rate, a.FromCurrencyCode, a.ToCurrencyCode)
+	}
+
+	// Dates are ISO 8601 timestamps, only the day matters
+	date := a.Date
+	if len(date) > 10 {
+		date = date[:10]
+	}
+
+	return ExchangeRate{
+		ID:        id,
+		From:      a.FromCurrencyCode,
+		To:        a.ToCurrencyCode,
+		Date:      date,
+		Rate:      rate,
+		CreatedAt: parseTime(a.CreatedAt),
+		UpdatedAt: parseTime(a.UpdatedAt),
+	}, nil
+}
+
+// parseTime parses an ISO 8601 timestamp, returning the zero time when it is missing or invalid.
+func parseTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339, value)
+	return t
+}
+
+// toExchangeRates converts resources to rates, keeping those within the range of the options.
+func toExchangeRates(resources []resource[ExchangeRateAttributes], options ListOptions) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	for _, r := range resources {
+		rate, err := r.Attributes.toExchangeRate(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		if options.includes(rate.Date) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// ListExchangeRates returns the exchange rates stored in Firefly III, reading every page.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - options: the date range and page size; Page is ignored.
+//
+// Returns:
+//   - A slice of ExchangeRate and an error if the operation fails.
+func (api *Api) ListExchangeRates(ctx context.Context, options ListOptions) ([]ExchangeRate, error) {
+
+	resources, err := listAll[ExchangeRateAttributes](ctx, api, fmt.Sprintf(ExchangeRateTemplate, api.Config.ApiUrl), options.query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	return toExchangeRates(resources, options)
+}
+
+// ListExchangeRatesPage returns one page of the exchange rates stored in Firefly III.
+// Unlike ListExchangeRates, the page is not filtered locally, so it matches its pagination.
+// Firefly III versions that ignore start and end return rates outside the range.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - options: the date range, page and page size.
+//
+// Returns:
+//   - The ExchangeRatePage and an error if the operation fails.
+func (api *Api) ListExchangeRatesPage(ctx context.Context, options ListOptions) (ExchangeRatePage, error) {
+
+	page := options.Page
+	if page < 1 {
+		page = 1
+	}
+
+	resources, pagination, err := listPage[ExchangeRateAttributes](ctx, api, fmt.Sprintf(ExchangeRateTemplate, api.Config.ApiUrl), options.query(), page)
+	if err != nil {
+		return ExchangeRatePage{}, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+
+	// Filtering here would leave fewer rates than Count and Total say
+	rates, err := toExchangeRates(resources, ListOptions{})
+	if err != nil {
+		return ExchangeRatePage{}, err
+	}
+	return ExchangeRatePage{Rates: rates, Pagination: pagination}, nil
+}
+
+// ListExchangeRatesByPair returns the exchange rates of the pair on all dates, reading every page.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - from: the source currency code.
+//   - to: the target currency code.
+//   - options: the date range and page size; Page is ignored.
+//
+// Returns:
+//   - A slice of ExchangeRate and an error if the operation fails.
+func (api *Api) ListExchangeRatesByPair(ctx context.Context, from string, to string, options ListOptions) ([]ExchangeRate, error) {
+
+	endpoint := fmt.Sprintf(ExchangeRateByPairTemplate, api.Config.ApiUrl, url.PathEscape(from), url.PathEscape(to))
+	resources, err := listAll[ExchangeRateAttributes](ctx, api, endpoint, options.query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates %s/%s: %w", from, to, err)
+	}
+	return toExchangeRates(resources, options)
+}
+
+// GetExchangeRateById returns the exchange rate with the Firefly III identifier.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - id: the Firefly III identifier of the rate.
+//
+// Returns:
+//   - The ExchangeRate and an error if the operation fails.
+func (api *Api) GetExchangeRateById(ctx context.Context, id string) (ExchangeRate, error) {
+
+	var doc ExchangeRateDocument
+	if err := api.request(ctx, "GET", fmt.Sprintf(ExchangeRateByIdTemplate, api.Config.ApiUrl, url.PathEscape(id)), nil, &doc); err != nil {
+		return ExchangeRate{}, fmt.Errorf("failed to get exchange rate %s: %w", id, err)
+	}
+	return doc.Data.Attributes.toExchangeRate(doc.Data.ID)
+}
+
+// UpdateExchangeRate changes the value of an existing exchange rate.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - id: the Firefly III identifier of the rate.
+//   - date: the date of the rate (in "YYYY-MM-DD" format).
+//   - rate: the new exchange rate value.
+//
+// Returns:
+//   - An error if the operation fails; otherwise, nil.
+func (api *Api) UpdateExchangeRate(ctx context.Context, id string, date string, rate float64) error {
+
+	payload := map[string]string{
+		"date": date,
+		"rate": fmt.Sprintf("%.8f", rate),
+	}
+
+	err := api.request(ctx, "PUT", fmt.Sprintf(ExchangeRateByIdTemplate, api.Config.ApiUrl, url.PathEscape(id)), payload, nil, "id", id, "date", date)
+	if err != nil {
+		return fmt.Errorf("failed to update exchange rate %s on %s: %w", id, date, err)
+	}
+	return nil
+}
+
+// GetExchangeRate returns the exchange rate for the pair on the date.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - from: the source currency code.
+//   - to: the target currency code.
+//   - date: the date of the rate (in "YYYY-MM-DD" format).
+//
+// Returns:
+//   - The ExchangeRate, whether it exists, and an error if the operation fails.
+func (api *Api) GetExchangeRate(ctx context.Context, from string, to string, date string) (ExchangeRate, bool, error) {
+
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	err := api.request(ctx, "GET", fmt.Sprintf(ExchangeRateByPairDateTemplate, api.Config.ApiUrl, url.PathEscape(from), url.PathEscape(to), date), nil, &doc)
+	if IsNotFound(err) {
+		return ExchangeRate{}, false, nil
+	}
+	if err != nil {
+		return ExchangeRate{}, false, fmt.Errorf("failed to get exchange rate %s/%s on %s: %w", from, to, date, err)
+	}
+
+	// The endpoint answers with a single resource or a list of them
+	var resources []resource[ExchangeRateAttributes]
+	if err := json.Unmarshal(doc.Data, &resources); err != nil {
+		var single resource[ExchangeRateAttributes]
+		if err := json.Unmarshal(doc.Data, &single); err != nil {
+			return ExchangeRate{}, false, fmt.Errorf("failed to parse exchange rate %s/%s on %s: %v", from, to, date, err)
+		}
+		resources = append(resources, single)
+	}
+
+	for _, r := range resources {
+		rate, err := r.Attributes.toExchangeRate(r.ID)
+		if err != nil {
+			return ExchangeRate{}, false, err
+		}
+		if rate.Date == date {
+			return rate, true, nil
+		}
+	}
+	return ExchangeRate{}, false, nil
+}
+
+// DeleteExchangeRate deletes the exchange rate for the pair on the date.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - from: the source currency code.
+//   - to: the target currency code.
+//   - date: the date of the rate (in "YYYY-MM-DD" format).
+//
+// Returns:
+//   - An error if the operation fails; otherwise, nil. IsNotFound reports a missing rate.
+func (api *Api) DeleteExchangeRate(ctx context.Context, from string, to string, date string) error {
+
+	err := api.request(ctx, "DELETE", fmt.Sprintf(ExchangeRateByPairDateTemplate, api.Config.ApiUrl, url.PathEscape(from), url.PathEscape(to), date), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate %s/%s on %s: %w", from, to, date, err)
+	}
+	return nil
+}
+
+// DeleteExchangeRateById deletes the exchange rate with the Firefly III identifier.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - id: the Firefly III identifier of the rate.
+//
+// Returns:
+//   - An error if the operation fails; otherwise, nil.
+func (api *Api) DeleteExchangeRateById(ctx context.Context, id string) error {
+
+	err := api.request(ctx, "DELETE", fmt.Sprintf(ExchangeRateByIdTemplate, api.Config.ApiUrl, url.PathEscape(id)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteExchangeRatesByPair deletes the exchange rates of the pair on all dates.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - from: the source currency code.
+//   - to: the target currency code.
+//
+// Returns:
+//   - An error if the operation fails; otherwise, nil.
+func (api *Api) DeleteExchangeRatesByPair(ctx context.Context, from string, to string) error {
+
+	err := api.request(ctx, "DELETE", fmt.Sprintf(ExchangeRateByPairTemplate, api.Config.ApiUrl, url.PathEscape(from), url.PathEscape(to)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rates %s/%s: %w", from, to, err)
+	}
+	return nil
+}
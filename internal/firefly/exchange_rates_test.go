@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/firefly/fireflytest"
+)
+
+func TestListExchangeRatesRange(t *testing.T) {
+
+	rates := []fireflytest.Rate{
+		{From: "USD", To: "EUR", Date: "2024-12-31", Rate: 0.96},
+		{From: "USD", To: "EUR", Date: "2025-01-02", Rate: 0.97},
+		{From: "USD", To: "GBP", Date: "2025-01-02", Rate: 0.80},
+		{From: "USD", To: "EUR", Date: "2025-02-01", Rate: 0.95},
+	}
+	options := firefly.ListOptions{Start: "2025-01-01", End: "2025-01-31", Limit: 2}
+
+	tests := []struct {
+		name        string
+		ignoreDates bool
+		wantAll     int
+		wantPage    int
+		wantTotal   int
+	}{
+		{"server filters", false, 2, 2, 2},
+		{"server ignores the range", true, 2, 2, 4},
+	}
+	for _, tt := range tests {
+		server := fireflytest.NewServer(rates...)
+		server.IgnoreDates = tt.ignoreDates
+		api := server.Client()
+
+		all, err := api.ListExchangeRates(context.Background(), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != tt.wantAll {
+			t.Errorf("%s: ListExchangeRates() = %d rates, want %d", tt.name, len(all), tt.wantAll)
+		}
+
+		page, err := api.ListExchangeRatesPage(context.Background(), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Rates) != tt.wantPage || page.Pagination.Count != len(page.Rates) || page.Pagination.Total != tt.wantTotal {
+			t.Errorf("%s: page of %d rates with count %d and total %d, want %d rates of %d", tt.name, len(page.Rates), page.Pagination.Count, page.Pagination.Total, tt.wantPage, tt.wantTotal)
+		}
+		server.Close()
+	}
+}
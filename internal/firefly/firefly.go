@@ -16,11 +16,9 @@ limitations under the License.
 package firefly
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"strings"
+	"sort"
 	"time"
 )
 
@@ -49,14 +47,15 @@ func NewApi(config ApiConfig) *Api {
 // SendExchangeRate sends the exchange rate data to the Firefly API.
 //
 // Parameters:
+//   - ctx: the context that cancels the request.
 //   - rate: the exchange rate value to be sent.
-//   - fromCurrency: the source currency code (e.g., "USD").
-//   - toCurrency: the target currency code (e.g., "EUR").
+//   - fromCurrency: the source currency code as it is defined in Firefly III (e.g., "USD").
+//   - toCurrency: the target currency code as it is defined in Firefly III (e.g., "EUR").
 //   - date: the date for which the exchange rate is applicable (in "YYYY-MM-DD" format). If empty, the current date is used.
 //
 // Returns:
 //   - An error if the operation fails; otherwise, nil.
-func (api *Api) SendExchangeRate(rate float64, fromCurrency string, toCurrency string, date string) error {
+func (api *Api) SendExchangeRate(ctx context.Context, rate float64, fromCurrency string, toCurrency string, date string) error {
 
 	if date == "" {
 		date = time.Now().Format("2006-01-02")
@@ -64,34 +63,15 @@ func (api *Api) SendExchangeRate(rate float64, fromCurrency string, toCurrency s
 
 	payload := map[string]string{
 		"date": date,
-		"from": strings.ToUpper(fromCurrency),
-		"to":   strings.ToUpper(toCurrency),
+		"from": fromCurrency,
+		"to":   toCurrency,
 		"rate": fmt.Sprintf("%.8f", rate),
 	}
 
 	endpoint := fmt.Sprintf(ExchangeRateTemplate, api.Config.ApiUrl)
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/vnd.api+json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Config.ApiKey))
-
-	client := &http.Client{Timeout: time.Duration(api.Config.TimeoutSeconds) * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send exchange rate: %d", resp.StatusCode)
+	if err := api.request(ctx, "POST", endpoint, payload, nil, "base", fromCurrency, "pair", fromCurrency+"/"+toCurrency, "date", date); err != nil {
+		return fmt.Errorf("failed to send exchange rate %s/%s on %s: %w", fromCurrency, toCurrency, date, err)
 	}
 
 	return nil
@@ -100,13 +80,24 @@ func (api *Api) SendExchangeRate(rate float64, fromCurrency string, toCurrency s
 // SendExchangeRateByDate sends multiple exchange rates for a specific date to the Firefly API.
 //
 // Parameters:
-//   - fromCurrency: the source currency code (e.g., "USD").
+//   - ctx: the context that cancels the request.
+//   - fromCurrency: the source currency code as it is defined in Firefly III (e.g., "USD").
 //   - rates: a map of target currency codes to their corresponding exchange rate values.
 //   - date: the date for which the exchange rates are applicable (in "YYYY-MM-DD" format). If empty, the current date is used.
 //
 // Returns:
 //   - An error if the operation fails; otherwise, nil.
-func (api *Api) SendExchangeRateByDate(fromCurrency string, rates map[string]float64, date string) error {
+func (api *Api) SendExchangeRateByDate(ctx context.Context, fromCurrency string, rates map[string]float64, date string) error {
+	_, err := api.PostExchangeRatesByDate(ctx, fromCurrency, rates, date)
+	return err
+}
+
+// PostExchangeRatesByDate is SendExchangeRateByDate that also returns the HTTP status of the response.
+//
+// Returns:
+//   - The status code, 0 when no response was received.
+//   - An error if the operation fails; otherwise, nil.
+func (api *Api) PostExchangeRatesByDate(ctx context.Context, fromCurrency string, rates map[string]float64, date string) (int, error) {
 
 	if date == "" {
 		date = time.Now().Format("2006-01-02")
@@ -115,36 +106,24 @@ func (api *Api) SendExchangeRateByDate(fromCurrency string, rates map[string]flo
 
 	payload_rates := make(map[string]string)
 	for k, v := range rates {
-		payload_rates[strings.ToUpper(k)] = fmt.Sprintf("%.8f", v)
+		payload_rates[k] = fmt.Sprintf("%.8f", v)
 	}
 
 	payload := map[string]interface{}{
-		"from":  strings.ToUpper(fromCurrency),
+		"from":  fromCurrency,
 		"rates": payload_rates,
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+	var pairs []string
+	for to := range rates {
+		pairs = append(pairs, fromCurrency+"/"+to)
 	}
-	req.Header.Set("Content-Type", "application/vnd.api+json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Config.ApiKey))
+	sort.Strings(pairs)
 
-	client := &http.Client{Timeout: time.Duration(api.Config.TimeoutSeconds) * time.Second}
-	resp, err := client.Do(req)
+	status, err := api.do(ctx, "POST", endpoint, payload, nil, "base", fromCurrency, "pairs", pairs, "date", date)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+		return status, fmt.Errorf("failed to send exchange rates of %s on %s: %w", fromCurrency, date, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send exchange rate: %d", resp.StatusCode)
-	}
-
-	return nil
+	return status, nil
 }
@@ -0,0 +1,288 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fireflytest provides an in-memory Firefly III exchange rate API for tests.
+package fireflytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+// Rate is an exchange rate stored by the Server.
+type Rate struct {
+	ID   string
+	From string
+	To   string
+	Date string
+	Rate float64
+}
+
+// Server is a Firefly III instance holding exchange rates in memory. Its API URL is URL + "/api/v1".
+type Server struct {
+	*httptest.Server
+
+	// Fail, when set, is called for every request; a non-zero status is returned instead of handling it.
+	Fail func(method string, path string) int
+	// IgnoreDates makes the list endpoints ignore start and end, like older Firefly III versions.
+	IgnoreDates bool
+	// PerPage is the default page size of the list endpoints; 0 means 50.
+	PerPage int
+
+	mu       sync.Mutex
+	rates    []*Rate
+	nextID   int
+	requests []string
+}
+
+// NewServer starts a Server holding the rates. Close it when done.
+func NewServer(rates ...Rate) *Server {
+	s := &Server{}
+	for _, r := range rates {
+		s.put(r.From, r.To, r.Date, r.Rate)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Client returns a Firefly III client of the server.
+func (s *Server) Client() *firefly.Api {
+	return firefly.NewApi(firefly.ApiConfig{ApiUrl: s.URL + "/api/v1", ApiKey: "test-key", TimeoutSeconds: 10})
+}
+
+// Rates returns the stored rates sorted by date and pair.
+func (s *Server) Rates() []Rate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rates []Rate
+	for _, r := range s.rates {
+		rates = append(rates, *r)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return rates
+}
+
+// Requests returns the handled requests as "METHOD path?query".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+path+"?"+r.URL.RawQuery)
+	s.mu.Unlock()
+
+	if s.Fail != nil {
+		if status := s.Fail(r.Method, path); status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && path == "/exchange-rates":
+		s.list(w, r, s.rates)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[1] == "by-date":
+		var payload struct {
+			From  string            `json:"from"`
+			Rates map[string]string `json:"rates"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for to, value := range payload.Rates {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			s.put(payload.From, to, parts[2], rate)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": []}`))
+	case len(parts) == 4 && parts[1] == "rates" && r.Method == http.MethodGet:
+		var pair []*Rate
+		for _, rate := range s.rates {
+			if rate.From == parts[2] && rate.To == parts[3] {
+				pair = append(pair, rate)
+			}
+		}
+		s.list(w, r, pair)
+	case len(parts) == 5 && parts[1] == "rates":
+		i := s.find(parts[2], parts[3], parts[4])
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]any{"data": []any{resource(s.rates[i])}})
+		case http.MethodDelete:
+			s.rates = append(s.rates[:i], s.rates[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2:
+		i := -1
+		for j, rate := range s.rates {
+			if rate.ID == parts[1] {
+				i = j
+			}
+		}
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]any{"data": resource(s.rates[i])})
+		case http.MethodPut:
+			var payload struct {
+				Date string `json:"date"`
+				Rate string `json:"rate"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rate, err := strconv.ParseFloat(payload.Rate, 64)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			s.rates[i].Rate = rate
+			writeJSON(w, map[string]any{"data": resource(s.rates[i])})
+		case http.MethodDelete:
+			s.rates = append(s.rates[:i], s.rates[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// list writes one page of the rates, filtered by the start and end of the query.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, rates []*Rate) {
+
+	query := r.URL.Query()
+	start, end := query.Get("start"), query.Get("end")
+
+	var filtered []*Rate
+	for _, rate := range rates {
+		if !s.IgnoreDates && ((start != "" && rate.Date < start) || (end != "" && rate.Date > end)) {
+			continue
+		}
+		filtered = append(filtered, rate)
+	}
+
+	perPage := s.PerPage
+	if perPage <= 0 {
+		perPage = 50
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+		perPage = limit
+	}
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	totalPages := (len(filtered) + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	data := []any{}
+	for i := (page - 1) * perPage; i < page*perPage && i < len(filtered); i++ {
+		data = append(data, resource(filtered[i]))
+	}
+	writeJSON(w, map[string]any{
+		"data": data,
+		"meta": map[string]any{"pagination": map[string]any{
+			"total":        len(filtered),
+			"count":        len(data),
+			"per_page":     perPage,
+			"current_page": page,
+			"total_pages":  totalPages,
+		}},
+	})
+}
+
+// put stores the rate, replacing the rate of the pair on the date.
+func (s *Server) put(from string, to string, date string, rate float64) {
+	if i := s.find(from, to, date); i >= 0 {
+		s.rates[i].Rate = rate
+		return
+	}
+	s.nextID++
+	s.rates = append(s.rates, &Rate{ID: strconv.Itoa(s.nextID), From: from, To: to, Date: date, Rate: rate})
+}
+
+func (s *Server) find(from string, to string, date string) int {
+	for i, rate := range s.rates {
+		if rate.From == from && rate.To == to && rate.Date == date {
+			return i
+		}
+	}
+	return -1
+}
+
+func resource(rate *Rate) map[string]any {
+	return map[string]any{
+		"type": "currency_exchange_rates",
+		"id":   rate.ID,
+		"attributes": firefly.ExchangeRateAttributes{
+			FromCurrencyCode: rate.From,
+			ToCurrencyCode:   rate.To,
+			Rate:             fmt.Sprintf("%.8f", rate.Rate),
+			Date:             rate.Date + "T00:00:00+00:00",
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// resource is a single JSON:API resource object.
+type resource[T any] struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Attributes T      `json:"attributes"`
+}
+
+// document is a JSON:API document with a single resource.
+type document[T any] struct {
+	Data resource[T] `json:"data"`
+}
+
+// Pagination describes the page of a list response.
+type Pagination struct {
+	// Total is the number of resources on all pages.
+	Total int `json:"total"`
+	// Count is the number of resources on this page.
+	Count int `json:"count"`
+	// PerPage is the page size.
+	PerPage     int `json:"per_page"`
+	CurrentPage int `json:"current_page"`
+	TotalPages  int `json:"total_pages"`
+}
+
+// listDocument is a JSON:API document with a list of resources.
+type listDocument[T any] struct {
+	Data []resource[T] `json:"data"`
+	Meta struct {
+		Pagination Pagination `json:"pagination"`
+	} `json:"meta"`
+}
+
+// StatusError is returned when the Firefly III API answers with an unexpected status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// IsNotFound returns true if the error is, or wraps, a StatusError with status 404.
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// request sends a request to the API and decodes the JSON response into out, if not nil.
+// The request is cancelled with ctx or after the configured timeout, whichever comes first.
+// Failed requests are logged at warn level with attrs, key-value pairs such as the base currency.
+func (api *Api) request(ctx context.Context, method string, endpoint string, payload any, out any, attrs ...any) error {
+	_, err := api.do(ctx, method, endpoint, payload, out, attrs...)
+	return err
+}
+
+// do is request that also returns the HTTP status of the response, 0 when none was received.
+func (api *Api) do(ctx context.Context, method string, endpoint string, payload any, out any, attrs ...any) (int, error) {
+
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal payload: %v", err)
+		}
+		body = bytes.NewBuffer(raw)
+	}
+
+	if api.Config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(api.Config.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", api.Config.ApiKey))
+
+	attrs = append([]any{"method", method, "url", endpoint}, attrs...)
+
+	resp, err := api.client().Do(req)
+	if err != nil {
+		slog.Warn("Firefly III request failed", append(attrs, "error", err)...)
+		// Request errors are wrapped up to the caller, so a cancelled run stays context.Canceled
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	attrs = append(attrs, "status", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotFound && method == http.MethodGet:
+		// Lookups of rates that do not exist yet are expected
+		slog.Debug("Firefly III request", attrs...)
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		slog.Warn("Firefly III request failed", attrs...)
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode}
+	}
+	slog.Debug("Firefly III request", attrs...)
+
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %v", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// client returns the configured HTTP client or http.DefaultClient.
+func (api *Api) client() *http.Client {
+	if api.Config.HTTPClient != nil {
+		return api.Config.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// listPage fetches one page of a list endpoint.
+func listPage[T any](ctx context.Context, api *Api, endpoint string, query url.Values, page int) ([]resource[T], Pagination, error) {
+
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(page))
+
+	var document listDocument[T]
+	if err := api.request(ctx, "GET", endpoint+"?"+q.Encode(), nil, &document); err != nil {
+		return nil, Pagination{}, err
+	}
+	return document.Data, document.Meta.Pagination, nil
+}
+
+// listAll fetches every page of a list endpoint.
+func listAll[T any](ctx context.Context, api *Api, endpoint string, query url.Values) ([]resource[T], error) {
+
+	var resources []resource[T]
+	for page := 1; ; page++ {
+		data, pagination, err := listPage[T](ctx, api, endpoint, query, page)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, data...)
+
+		if page >= pagination.TotalPages {
+			break
+		}
+	}
+	return resources, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly/fireflytest"
+)
+
+func TestFailedRequestsLogged(t *testing.T) {
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	server := fireflytest.NewServer(fireflytest.Rate{From: "USD", To: "EUR", Date: "2025-01-02", Rate: 0.9})
+	defer server.Close()
+	server.Fail = func(method string, path string) int {
+		if method != http.MethodGet {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	api := server.Client()
+	ctx := context.Background()
+
+	// A missing rate is an expected lookup result, not a warning
+	if _, found, err := api.GetExchangeRate(ctx, "USD", "GBP", "2025-01-02"); err != nil || found {
+		t.Fatalf("GetExchangeRate() = %v, %v; want not found", found, err)
+	}
+	if err := api.SendExchangeRateByDate(ctx, "USD", map[string]float64{"GBP": 0.8, "EUR": 0.91}, "2025-01-02"); err == nil {
+		t.Fatal("expected the send to fail")
+	}
+	if err := api.UpdateExchangeRate(ctx, "1", "2025-01-02", 0.91); err == nil {
+		t.Fatal("expected the update to fail")
+	}
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("invalid log line %s: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("logged %d warnings, want 2: %s", len(records), logs.String())
+	}
+
+	send, update := records[0], records[1]
+	if send["level"] != "WARN" || send["base"] != "USD" || send["date"] != "2025-01-02" || send["status"] != float64(500) {
+		t.Errorf("send warning %v, want base, date and status", send)
+	}
+	if pairs, _ := send["pairs"].([]any); len(pairs) != 2 || pairs[0] != "USD/EUR" || pairs[1] != "USD/GBP" {
+		t.Errorf("send warning pairs %v, want USD/EUR and USD/GBP", send["pairs"])
+	}
+	if update["level"] != "WARN" || update["id"] != "1" || update["date"] != "2025-01-02" || update["method"] != "PUT" {
+		t.Errorf("update warning %v, want id, date and method", update)
+	}
+}
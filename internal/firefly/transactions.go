@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package firefly
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const TransactionsTemplate = "%s/transactions"
+
+// transactionGroupAttributes are the attributes of a transactions resource, a group of splits.
+type transactionGroupAttributes struct {
+	Transactions []struct {
+		Date string `json:"date"`
+	} `json:"transactions"`
+}
+
+// GetTransactionDates returns the dates (YYYY-MM-DD) that have at least one transaction.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - start: the first date to look at (in "YYYY-MM-DD" format), or "" for no limit.
+//   - end: the last date to look at (in "YYYY-MM-DD" format), or "" for no limit.
+//
+// Returns:
+//   - A set of dates and an error if the operation fails.
+func (api *Api) GetTransactionDates(ctx context.Context, start string, end string) (map[string]bool, error) {
+
+	query := url.Values{}
+	query.Set("type", "all")
+	if start != "" {
+		query.Set("start", start)
+	}
+	if end != "" {
+		query.Set("end", end)
+	}
+
+	resources, err := listAll[transactionGroupAttributes](ctx, api, fmt.Sprintf(TransactionsTemplate, api.Config.ApiUrl), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	dates := make(map[string]bool)
+	for _, r := range resources {
+		for _, t := range r.Attributes.Transactions {
+			// Dates are ISO 8601 timestamps, only the day matters
+			date := t.Date
+			if len(date) > 10 {
+				date = date[:10]
+			}
+			dates[date] = true
+		}
+	}
+	return dates, nil
+}
@@ -0,0 +1,274 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package journal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+// Entry is a single rate written by a run.
+type Entry struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+	// Existed is true when the rate was already stored before the run, Previous then holds its value.
+	Existed  bool    `json:"existed"`
+	Previous float64 `json:"previous,omitempty"`
+	// Reverted is true once the entry has been undone, so a failed revert can be retried.
+	Reverted bool `json:"reverted,omitempty"`
+}
+
+// Run is the journal of one run: every rate it wrote to Firefly III.
+type Run struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"`
+	Target     string    `json:"target"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Entries    []Entry   `json:"entries"`
+	// RevertedAt is set once the run has been reverted.
+	RevertedAt *time.Time `json:"reverted_at,omitempty"`
+
+	// mu guards Entries while batches are sent concurrently
+	mu sync.Mutex
+}
+
+// DefaultDir returns the default journal directory, $XDG_STATE_HOME/ffiii-rate-updater/runs
+// or ~/.local/state/ffiii-rate-updater/runs.
+func DefaultDir() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "ffiii-rate-updater", "runs")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "runs")
+	}
+	return filepath.Join(home, ".local", "state", "ffiii-rate-updater", "runs")
+}
+
+// NewRun starts the journal of a new run with a unique, time-ordered ID.
+func NewRun(command string, target string) *Run {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+
+	now := time.Now().UTC()
+	return &Run{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Command:   command,
+		Target:    target,
+		StartedAt: now,
+	}
+}
+
+// SendExchangeRateByDate sends the rates through the Firefly III client and records them.
+// The current value of every rate is looked up first, so the run can be reverted exactly.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client.
+//   - fromCurrency: the source currency code as it is defined in Firefly III.
+//   - rates: a map of target currency codes to their exchange rate values.
+//   - date: the date of the rates (in "YYYY-MM-DD" format).
+//
+// Returns:
+//   - The HTTP status of the send, 0 when it was not attempted or no response was received.
+//   - An error if the lookup or the send fails; nothing is recorded then.
+func (r *Run) SendExchangeRateByDate(ctx context.Context, api *firefly.Api, fromCurrency string, rates map[string]float64, date string) (int, error) {
+
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	var entries []Entry
+	for to, rate := range rates {
+		previous, existed, err := api.GetExchangeRate(ctx, fromCurrency, to, date)
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, Entry{
+			Date:     date,
+			From:     fromCurrency,
+			To:       to,
+			Rate:     rate,
+			Existed:  existed,
+			Previous: previous.Rate,
+		})
+	}
+
+	status, err := api.PostExchangeRatesByDate(ctx, fromCurrency, rates, date)
+	if err != nil {
+		return status, err
+	}
+
+	r.mu.Lock()
+	r.Entries = append(r.Entries, entries...)
+	r.mu.Unlock()
+	return status, nil
+}
+
+// Save writes the run to the journal directory.
+func Save(dir string, run *Run) error {
+	if run.FinishedAt.IsZero() {
+		run.FinishedAt = time.Now().UTC()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// Batches finish in any order, the journal lists the rates by date and pair
+	sort.Slice(run.Entries, func(i, j int) bool {
+		a, b := run.Entries[i], run.Entries[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	body, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, run.ID+".json"), body, 0o644)
+}
+
+// Load reads the run with the given ID from the journal directory.
+func Load(dir string, id string) (*Run, error) {
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("run %s not found in %s", id, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var run Run
+	if err := json.Unmarshal(body, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %v", id, err)
+	}
+	return &run, nil
+}
+
+// List returns all runs in the journal directory, newest first.
+func List(dir string) ([]*Run, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		run, err := Load(dir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID > runs[j].ID })
+	return runs, nil
+}
+
+// Revert undoes the run: rates it created are deleted and rates it overwrote get their previous value back.
+// Every undone entry is marked as reverted, so when a request fails the run can be saved and the
+// revert retried; it continues with the remaining entries. A rate that is already gone counts as deleted.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client of the instance the run wrote to.
+//   - run: the run to revert.
+//
+// Returns:
+//   - The number of rates reverted by this call and an error if a request fails.
+func Revert(ctx context.Context, api *firefly.Api, run *Run) (int, error) {
+
+	if run.RevertedAt != nil {
+		return 0, fmt.Errorf("run %s was already reverted at %s", run.ID, run.RevertedAt.Format(time.RFC3339))
+	}
+
+	reverted := 0
+	for i := range run.Entries {
+		e := &run.Entries[i]
+		if e.Reverted {
+			continue
+		}
+
+		if !e.Existed {
+			if err := api.DeleteExchangeRate(ctx, e.From, e.To, e.Date); err != nil && !firefly.IsNotFound(err) {
+				return reverted, err
+			}
+			e.Reverted = true
+			reverted++
+			continue
+		}
+
+		current, found, err := api.GetExchangeRate(ctx, e.From, e.To, e.Date)
+		if err != nil {
+			return reverted, err
+		}
+		if !found {
+			// Deleted since the run, send the previous value again
+			err = api.SendExchangeRateByDate(ctx, e.From, map[string]float64{e.To: e.Previous}, e.Date)
+		} else {
+			err = api.UpdateExchangeRate(ctx, current.ID, e.Date, e.Previous)
+		}
+		if err != nil {
+			return reverted, fmt.Errorf("failed to restore %s/%s on %s: %v", e.From, e.To, e.Date, err)
+		}
+		e.Reverted = true
+		reverted++
+	}
+
+	now := time.Now().UTC()
+	run.RevertedAt = &now
+	return reverted, nil
+}
+
+// RevertedEntries returns the number of entries that have been undone.
+func (r *Run) RevertedEntries() int {
+	count := 0
+	for _, e := range r.Entries {
+		if e.Reverted {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package journal
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly/fireflytest"
+)
+
+func TestRunRevert(t *testing.T) {
+
+	server := fireflytest.NewServer(fireflytest.Rate{From: "USD", To: "EUR", Date: "2025-01-02", Rate: 0.9})
+	defer server.Close()
+	api := server.Client()
+	ctx := context.Background()
+
+	run := NewRun("update", api.Config.ApiUrl)
+	if _, err := run.SendExchangeRateByDate(ctx, api, "USD", map[string]float64{"EUR": 0.95, "GBP": 0.8, "JPY": 150}, "2025-01-02"); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := Save(dir, run); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first attempt fails on JPY after deleting GBP
+	server.Fail = func(method string, path string) int {
+		if method == http.MethodDelete && path == "/exchange-rates/rates/USD/JPY/2025-01-02" {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	reverted, err := Revert(ctx, api, run)
+	if err == nil {
+		t.Fatal("expected the first revert to fail")
+	}
+	if reverted != 2 || run.RevertedEntries() != 2 || run.RevertedAt != nil {
+		t.Fatalf("first revert: reverted %d, entries %d, reverted at %v; want 2, 2, nil", reverted, run.RevertedEntries(), run.RevertedAt)
+	}
+	if err := Save(dir, run); err != nil {
+		t.Fatal(err)
+	}
+
+	// The JPY delete went through although its response was lost: the retry skips EUR and GBP,
+	// and the 404 for JPY is no error
+	server.Fail = nil
+	if err := api.DeleteExchangeRate(ctx, "USD", "JPY", "2025-01-02"); err != nil {
+		t.Fatal(err)
+	}
+	run, err = Load(dir, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted, err = Revert(ctx, api, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted != 1 || run.RevertedAt == nil {
+		t.Fatalf("retry: reverted %d, reverted at %v; want 1 and set", reverted, run.RevertedAt)
+	}
+
+	rates := server.Rates()
+	if len(rates) != 1 || rates[0].To != "EUR" || rates[0].Rate != 0.9 {
+		t.Errorf("rates after revert = %+v, want only USD/EUR 0.9", rates)
+	}
+
+	if _, err := Revert(ctx, api, run); err == nil {
+		t.Error("expected an error when reverting a reverted run")
+	}
+}
+
+func TestRevertMissingRate(t *testing.T) {
+
+	server := fireflytest.NewServer()
+	defer server.Close()
+
+	run := &Run{ID: "test", Entries: []Entry{
+		{Date: "2025-01-02", From: "USD", To: "EUR", Rate: 0.95},
+		{Date: "2025-01-02", From: "USD", To: "GBP", Rate: 0.8, Existed: true, Previous: 0.79},
+	}}
+	reverted, err := Revert(context.Background(), server.Client(), run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted != 2 {
+		t.Errorf("reverted %d, want 2", reverted)
+	}
+
+	// A created rate that is gone stays gone, an overwritten one is restored
+	rates := server.Rates()
+	if len(rates) != 1 || rates[0].To != "GBP" || rates[0].Rate != 0.79 {
+		t.Errorf("rates after revert = %+v, want only USD/GBP 0.79", rates)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package limit
+
+import (
+	"context"
+	"sync"
+)
+
+// Run calls fn for every index from 0 to n-1 on a pool of at most workers goroutines.
+// Callers store results by index, so the output order does not depend on scheduling.
+// After the first failure no new calls are started and the context passed to running
+// calls is cancelled.
+//
+// Parameters:
+//   - ctx: the context of the calls.
+//   - workers: the maximum number of concurrent calls; less than 1 means 1.
+//   - n: the number of calls.
+//   - fn: the call for one index.
+//
+// Returns:
+//   - The error of the first failed call, or nil if all calls succeed.
+func Run(ctx context.Context, workers int, n int, fn func(ctx context.Context, i int) error) error {
+
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
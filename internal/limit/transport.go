@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package limit
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimit restricts the requests sent to one host.
+type HostLimit struct {
+	// Host is the host name the limit applies to (e.g. "api.coingecko.com").
+	Host string `mapstructure:"host"`
+	// MaxConcurrency is the maximum number of requests in flight; 0 means no limit.
+	MaxConcurrency int `mapstructure:"max_concurrency"`
+	// RequestsPerSecond is the maximum request rate; 0 means no limit.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+}
+
+// Validate checks the limit.
+func (l HostLimit) Validate() error {
+	if l.Host == "" {
+		return fmt.Errorf("rate limit requires a host")
+	}
+	if l.MaxConcurrency < 0 || l.RequestsPerSecond < 0 {
+		return fmt.Errorf("rate limit for %s must not be negative", l.Host)
+	}
+	return nil
+}
+
+// hostLimiter enforces a HostLimit.
+type hostLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// Transport is an http.RoundTripper that enforces per-host limits on top of a base transport.
+// Requests to hosts without a limit are passed through.
+type Transport struct {
+	Base http.RoundTripper
+
+	hosts map[string]*hostLimiter
+}
+
+// NewTransport creates a Transport with the limits.
+//
+// Parameters:
+//   - base: the transport that sends the requests; nil means http.DefaultTransport.
+//   - limits: the per-host limits.
+//
+// Returns:
+//   - The Transport and an error if a limit is invalid.
+func NewTransport(base http.RoundTripper, limits []HostLimit) (*Transport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &Transport{Base: base, hosts: make(map[string]*hostLimiter)}
+	for _, l := range limits {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+		limiter := &hostLimiter{}
+		if l.MaxConcurrency > 0 {
+			limiter.slots = make(chan struct{}, l.MaxConcurrency)
+		}
+		if l.RequestsPerSecond > 0 {
+			limiter.interval = time.Duration(float64(time.Second) / l.RequestsPerSecond)
+		}
+		t.hosts[strings.ToLower(l.Host)] = limiter
+	}
+	return t, nil
+}
+
+// RoundTrip waits for a free slot and the next allowed start time of the host, then sends the request.
+// The slot is held until the response body is closed, as the body is still being downloaded.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter, ok := t.hosts[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return t.Base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	if limiter.slots == nil {
+		return t.send(req, limiter)
+	}
+
+	select {
+	case limiter.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := sync.OnceFunc(func() { <-limiter.slots })
+
+	resp, err := t.send(req, limiter)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// send waits for the next allowed start time of the host and sends the request.
+func (t *Transport) send(req *http.Request, limiter *hostLimiter) (*http.Response, error) {
+	ctx := req.Context()
+	if limiter.interval > 0 {
+		limiter.mu.Lock()
+		now := time.Now()
+		start := limiter.next
+		if start.Before(now) {
+			start = now
+		}
+		limiter.next = start.Add(limiter.interval)
+		limiter.mu.Unlock()
+
+		if wait := time.Until(start); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	return t.Base.RoundTrip(req)
+}
+
+// slotBody releases the concurrency slot of its request when it is closed.
+type slotBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package limit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTransportHoldsSlotUntilBodyClosed(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("rates"))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	transport, err := NewTransport(nil, []HostLimit{{Host: u.Hostname(), MaxConcurrency: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+
+	first, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body of the first response is still open, so the second request waits
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the second request to wait for the slot")
+	}
+
+	io.ReadAll(first.Body)
+	if err := first.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing twice releases the slot once
+	first.Body.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request %d after close: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestTransportInterval(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	transport, err := NewTransport(nil, []HostLimit{{Host: u.Hostname(), RequestsPerSecond: 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests at 20 per second took %v, want at least 100ms", elapsed)
+	}
+}
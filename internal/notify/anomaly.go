@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+// AnomalyConfig decides which rate moves are anomalies.
+type AnomalyConfig struct {
+	// ThresholdPercent is the smallest change against the previous rate that is an anomaly.
+	ThresholdPercent float64 `mapstructure:"threshold_percent"`
+	// LookbackDays is how far back the previous rate is searched.
+	LookbackDays int `mapstructure:"lookback_days"`
+}
+
+// Validate checks the settings and fills in the defaults, 10 percent and 7 days.
+func (c *AnomalyConfig) Validate() error {
+	if c.ThresholdPercent < 0 || c.LookbackDays < 0 {
+		return fmt.Errorf("anomaly threshold_percent and lookback_days must not be negative")
+	}
+	if c.ThresholdPercent == 0 {
+		c.ThresholdPercent = 10
+	}
+	if c.LookbackDays == 0 {
+		c.LookbackDays = 7
+	}
+	return nil
+}
+
+// Anomaly is a sent rate that moved more than the threshold since the previous stored rate.
+type Anomaly struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Date          string  `json:"date"`
+	Rate          float64 `json:"rate"`
+	PreviousDate  string  `json:"previous_date"`
+	Previous      float64 `json:"previous"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s/%s moved %+.2f%% from %.6g on %s to %.6g on %s", a.From, a.To, a.ChangePercent, a.Previous, a.PreviousDate, a.Rate, a.Date)
+}
+
+// DetectAnomalies compares the sent rates of a run with the newest rates stored in Firefly III
+// before their date, within the lookback window.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client the rates were sent to.
+//   - run: the outcome of the run.
+//   - config: the anomaly settings, validated.
+//
+// Returns:
+//   - The anomalies, sorted by pair, and an error if the stored rates cannot be listed.
+func DetectAnomalies(ctx context.Context, api *firefly.Api, run Run, config AnomalyConfig) ([]Anomaly, error) {
+
+	var first, last string
+	for _, b := range run.Batches {
+		if !b.Sent {
+			continue
+		}
+		if first == "" || b.Date < first {
+			first = b.Date
+		}
+		if b.Date > last {
+			last = b.Date
+		}
+	}
+	if first == "" {
+		return nil, nil
+	}
+
+	start, err := time.Parse("2006-01-02", first)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %v", first, err)
+	}
+	end, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %v", last, err)
+	}
+
+	stored, err := api.ListExchangeRates(ctx, firefly.ListOptions{
+		Start: start.AddDate(0, 0, -config.LookbackDays).Format("2006-01-02"),
+		End:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list previous rates: %v", err)
+	}
+
+	byPair := make(map[string][]firefly.ExchangeRate)
+	for _, r := range stored {
+		byPair[r.From+"/"+r.To] = append(byPair[r.From+"/"+r.To], r)
+	}
+
+	var anomalies []Anomaly
+	for _, b := range run.Batches {
+		if !b.Sent {
+			continue
+		}
+		date, _ := time.Parse("2006-01-02", b.Date)
+		oldest := date.AddDate(0, 0, -config.LookbackDays).Format("2006-01-02")
+
+		for to, rate := range b.Rates {
+			var previous *firefly.ExchangeRate
+			for i, r := range byPair[b.From+"/"+to] {
+				if r.Date >= b.Date || r.Date < oldest || r.Rate <= 0 {
+					continue
+				}
+				if previous == nil || r.Date > previous.Date {
+					previous = &byPair[b.From+"/"+to][i]
+				}
+			}
+			if previous == nil {
+				continue
+			}
+
+			change := (rate - previous.Rate) / previous.Rate * 100
+			if math.Abs(change) < config.ThresholdPercent {
+				continue
+			}
+			anomalies = append(anomalies, Anomaly{
+				From:          b.From,
+				To:            to,
+				Date:          b.Date,
+				Rate:          rate,
+				PreviousDate:  previous.Date,
+				Previous:      previous.Rate,
+				ChangePercent: change,
+			})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].From != anomalies[j].From {
+			return anomalies[i].From < anomalies[j].From
+		}
+		return anomalies[i].To < anomalies[j].To
+	})
+	return anomalies, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command is the notifier that runs a local command.
+//
+// The command receives the event as JSON on stdin, and the trigger, the title and the
+// message as the environment variables FFIII_TRIGGER, FFIII_TITLE and FFIII_MESSAGE.
+// The placeholders {trigger} and {title} are replaced in its arguments.
+type Command struct {
+	name           string
+	command        []string
+	timeoutSeconds int
+}
+
+// NewCommand creates a new Command notifier with the provided configuration.
+func NewCommand(config Config) (*Command, error) {
+	if len(config.Command) == 0 {
+		return nil, fmt.Errorf("command notifier requires a command")
+	}
+	return &Command{
+		name:           config.Name,
+		command:        config.Command,
+		timeoutSeconds: config.TimeoutSeconds,
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Command) Name() string {
+	return n.name
+}
+
+// Notify runs the command with the event.
+func (n *Command) Notify(ctx context.Context, event Event) error {
+
+	input, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	replacer := strings.NewReplacer(
+		"{trigger}", event.Trigger,
+		"{title}", event.Title,
+	)
+	var args []string
+	for _, arg := range n.command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(n.timeoutSeconds)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, n.command[0], args...)
+	cmd.Env = append(os.Environ(),
+		"FFIII_TRIGGER="+event.Trigger,
+		"FFIII_TITLE="+event.Title,
+		"FFIII_MESSAGE="+event.Message,
+	)
+	cmd.Stdin = bytes.NewReader(input)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %d seconds", n.command[0], n.timeoutSeconds)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", n.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Triggers select the events a notifier receives.
+const (
+	// TriggerFailure is raised when a run sent no rates because of an error.
+	TriggerFailure = "failure"
+	// TriggerPartialFailure is raised when a run sent some rates but failed or skipped others.
+	TriggerPartialFailure = "partial_failure"
+	// TriggerAnomaly is raised when a sent rate moved more than the configured threshold.
+	TriggerAnomaly = "anomaly"
+	// TriggerSuccess is raised with a digest of the sent rates when a run succeeded.
+	TriggerSuccess = "success"
+)
+
+var triggers = []string{TriggerFailure, TriggerPartialFailure, TriggerAnomaly, TriggerSuccess}
+
+// Event is what a notifier is told about a run. Templates are executed on it.
+type Event struct {
+	// Trigger is one of TriggerFailure, TriggerPartialFailure, TriggerAnomaly or TriggerSuccess.
+	Trigger string `json:"trigger"`
+	// Title is a one-line summary, e.g. for a mail subject.
+	Title string `json:"title"`
+	// Message is a human-readable description of the event.
+	Message string `json:"message"`
+	// Report is the full report of the run, see Run.Report.
+	Report any `json:"report"`
+	// Anomalies are the large rate moves, set for TriggerAnomaly.
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+}
+
+// Run is what the notifications need to know about an update run.
+type Run struct {
+	// Provider is the name of the provider the rates came from.
+	Provider string
+	// Dates are the distinct dates of the batches, sorted.
+	Dates []string
+	// Sent, Failed and Skipped count the rates of the batches.
+	Sent    int
+	Failed  int
+	Skipped int
+	// Batches are the batches of the run, in the order of the currencies.
+	Batches []Batch
+	// Error is the error the run returned, empty on success.
+	Error string
+	// Report is the full report of the run, passed on to the events for templates and JSON.
+	Report any
+}
+
+// Batch is the outcome of sending the rates of one base currency.
+type Batch struct {
+	// From is the base currency.
+	From string
+	// Date is the date of the rates.
+	Date string
+	// Sent is true when Firefly III accepted the batch.
+	Sent bool
+	// Rates maps the target currencies to the value of one From.
+	Rates map[string]float64
+	// Skipped are the target currencies without a rate.
+	Skipped []SkippedRate
+	// Error is the reason the batch failed or was skipped.
+	Error string
+}
+
+// SkippedRate is a target currency that was not sent.
+type SkippedRate struct {
+	To     string
+	Reason string
+}
+
+// Notifier delivers events to a destination.
+type Notifier interface {
+	// Name returns the name of the notifier used in logs.
+	Name() string
+	// Notify delivers the event. The delivery is cancelled with ctx.
+	Notify(ctx context.Context, event Event) error
+}
+
+// Config holds the settings of a notifier from the notifiers section of the configuration.
+// Notifiers ignore the settings they do not use.
+type Config struct {
+	// Name identifies the notifier in logs; defaults to the type.
+	Name string `mapstructure:"name"`
+	// Type is webhook, smtp or command.
+	Type string `mapstructure:"type"`
+	// On are the triggers the notifier receives.
+	On []string `mapstructure:"on"`
+	// URL is the endpoint of a webhook.
+	URL string `mapstructure:"url"`
+	// Method is the HTTP method of a webhook; defaults to POST.
+	Method string `mapstructure:"method"`
+	// Headers are additional HTTP headers of a webhook, e.g. an access token.
+	Headers map[string]string `mapstructure:"headers"`
+	// Body is the template of the webhook request or of the mail body.
+	Body string `mapstructure:"body"`
+	// Subject is the template of the mail subject; defaults to the title.
+	Subject string `mapstructure:"subject"`
+	// Host and Port are the SMTP server; the port defaults to 587.
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// Username and Password authenticate at the SMTP server; leave empty for no authentication.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// From and To are the mail sender and recipients.
+	From string   `mapstructure:"from"`
+	To   []string `mapstructure:"to"`
+	// Command is the command and its arguments run by the command hook.
+	Command []string `mapstructure:"command"`
+	// TimeoutSeconds limits a delivery; defaults to 30.
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	// HTTPClient is the client used by webhooks.
+	// It is set by the caller, not read from the configuration. When nil, http.DefaultClient is used.
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+type notifierFactory func(config Config) (Notifier, error)
+
+var notifiers = map[string]notifierFactory{
+	"webhook": func(config Config) (Notifier, error) { return NewWebhook(config) },
+	"smtp":    func(config Config) (Notifier, error) { return NewSMTP(config) },
+	"command": func(config Config) (Notifier, error) { return NewCommand(config) },
+}
+
+// Types returns the sorted names of all notifier types.
+func Types() []string {
+	var names []string
+	for name := range notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Triggers returns the names of all triggers.
+func Triggers() []string {
+	return append([]string(nil), triggers...)
+}
+
+// Subscription is a notifier and the triggers it receives.
+type Subscription struct {
+	Notifier Notifier
+	On       []string
+}
+
+// Wants returns true if the subscription receives the trigger.
+func (s Subscription) Wants(trigger string) bool {
+	for _, t := range s.On {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// New creates the notifier of the configuration and subscribes it to its triggers.
+func New(config Config) (Subscription, error) {
+
+	factory, ok := notifiers[config.Type]
+	if !ok {
+		return Subscription{}, fmt.Errorf("unknown notifier type %q, expected one of: %s", config.Type, strings.Join(Types(), ", "))
+	}
+
+	if len(config.On) == 0 {
+		return Subscription{}, fmt.Errorf("notifier %q has no triggers, expected some of: %s", config.Type, strings.Join(triggers, ", "))
+	}
+	for _, t := range config.On {
+		known := false
+		for _, k := range triggers {
+			known = known || t == k
+		}
+		if !known {
+			return Subscription{}, fmt.Errorf("unknown trigger %q, expected one of: %s", t, strings.Join(triggers, ", "))
+		}
+	}
+
+	if config.Name == "" {
+		config.Name = config.Type
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = 30
+	}
+
+	notifier, err := factory(config)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("notifier %q: %v", config.Name, err)
+	}
+	return Subscription{Notifier: notifier, On: config.On}, nil
+}
+
+// Events returns the events of a run: the outcome of the run, and an anomaly event when
+// anomalies were found.
+//
+// Parameters:
+//   - run: the outcome of the run.
+//   - anomalies: the large rate moves of the run.
+//
+// Returns:
+//   - The events, the outcome first.
+func Events(run Run, anomalies []Anomaly) []Event {
+
+	var events []Event
+	switch {
+	case run.Error != "" && run.Sent == 0:
+		events = append(events, Event{
+			Trigger: TriggerFailure,
+			Title:   "Exchange rate update failed",
+			Message: run.Error,
+		})
+	case run.Error != "" || run.Failed > 0 || run.Skipped > 0:
+		message := fmt.Sprintf("Sent %d of %d rates.", run.Sent, run.Sent+run.Failed+run.Skipped)
+		for _, b := range run.Batches {
+			if b.Error != "" {
+				message += fmt.Sprintf("\n%s: %s", b.From, b.Error)
+			}
+			for _, s := range b.Skipped {
+				message += fmt.Sprintf("\n%s/%s: %s", b.From, s.To, s.Reason)
+			}
+		}
+		events = append(events, Event{
+			Trigger: TriggerPartialFailure,
+			Title:   "Exchange rate update partially failed",
+			Message: message,
+		})
+	default:
+		events = append(events, Event{
+			Trigger: TriggerSuccess,
+			Title:   "Exchange rates updated",
+			Message: digest(run),
+		})
+	}
+
+	if len(anomalies) > 0 {
+		var lines []string
+		for _, a := range anomalies {
+			lines = append(lines, a.String())
+		}
+		events = append(events, Event{
+			Trigger:   TriggerAnomaly,
+			Title:     fmt.Sprintf("%d exchange rates moved sharply", len(anomalies)),
+			Message:   strings.Join(lines, "\n"),
+			Anomalies: anomalies,
+		})
+	}
+
+	for i := range events {
+		events[i].Report = run.Report
+	}
+	return events
+}
+
+// digest lists the sent rates of a run, one line per base currency.
+func digest(run Run) string {
+
+	message := fmt.Sprintf("Sent %d rates from %s for %s.", run.Sent, run.Provider, strings.Join(run.Dates, ", "))
+	for _, b := range run.Batches {
+		if !b.Sent {
+			continue
+		}
+		var targets []string
+		for to := range b.Rates {
+			targets = append(targets, to)
+		}
+		sort.Strings(targets)
+
+		var rates []string
+		for _, to := range targets {
+			rates = append(rates, fmt.Sprintf("%s %.6g", to, b.Rates[to]))
+		}
+		message += fmt.Sprintf("\n%s: %s", b.From, strings.Join(rates, ", "))
+	}
+	return message
+}
+
+// Dispatch delivers every event to the subscriptions that want it. Failed deliveries are
+// logged and do not stop the others.
+//
+// Returns:
+//   - The number of failed deliveries.
+func Dispatch(ctx context.Context, subscriptions []Subscription, events []Event) int {
+
+	failed := 0
+	for _, event := range events {
+		for _, s := range subscriptions {
+			if !s.Wants(event.Trigger) {
+				continue
+			}
+			if err := s.Notifier.Notify(ctx, event); err != nil {
+				slog.Warn("Failed to send notification", "notifier", s.Notifier.Name(), "trigger", event.Trigger, "error", err)
+				failed++
+				continue
+			}
+			slog.Info("Sent notification", "notifier", s.Notifier.Name(), "trigger", event.Trigger)
+		}
+	}
+	return failed
+}
+
+// parseTemplate parses a template with the json function, which encodes a value as JSON,
+// e.g. {{json .Message}} for a quoted string.
+func parseTemplate(name string, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			raw, err := json.Marshal(v)
+			return string(raw), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	return t, nil
+}
+
+// render executes a template on the event.
+func render(t *template.Template, event Event) (string, error) {
+	var out strings.Builder
+	if err := t.Execute(&out, event); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %v", t.Name(), err)
+	}
+	return out.String(), nil
+}
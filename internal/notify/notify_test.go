@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+
+	sent := Batch{From: "USD", Sent: true, Rates: map[string]float64{"EUR": 0.97, "KGS": 87.45}}
+	anomaly := Anomaly{From: "USD", To: "KGS", Date: "2025-01-02", Rate: 87.45, PreviousDate: "2025-01-01", Previous: 70, ChangePercent: 24.93}
+
+	tests := []struct {
+		name      string
+		run       Run
+		anomalies []Anomaly
+		triggers  string
+		message   string
+	}{
+		{
+			name:     "failure",
+			run:      Run{Error: "invalid configuration"},
+			triggers: TriggerFailure,
+			message:  "invalid configuration",
+		},
+		{
+			name: "partial failure",
+			run: Run{
+				Error:   "1 batch failed",
+				Sent:    2,
+				Failed:  2,
+				Batches: []Batch{sent, {From: "EUR", Error: "unexpected status 500"}},
+			},
+			triggers: TriggerPartialFailure,
+			message:  "Sent 2 of 4 rates.\nEUR: unexpected status 500",
+		},
+		{
+			name:     "skipped",
+			run:      Run{Sent: 2, Skipped: 1, Batches: []Batch{{From: "USD", Sent: true, Skipped: []SkippedRate{{To: "XAU", Reason: "no rate"}}}}},
+			triggers: TriggerPartialFailure,
+			message:  "USD/XAU: no rate",
+		},
+		{
+			name:     "success",
+			run:      Run{Provider: "ecb", Dates: []string{"2025-01-02"}, Sent: 2, Batches: []Batch{sent}},
+			triggers: TriggerSuccess,
+			message:  "Sent 2 rates from ecb for 2025-01-02.\nUSD: EUR 0.97, KGS 87.45",
+		},
+		{
+			name:      "anomaly",
+			run:       Run{Sent: 2, Batches: []Batch{sent}},
+			anomalies: []Anomaly{anomaly},
+			triggers:  TriggerSuccess + "," + TriggerAnomaly,
+			message:   "USD/KGS moved +24.93% from 70 on 2025-01-01 to 87.45 on 2025-01-02",
+		},
+	}
+	for _, tt := range tests {
+		tt.run.Report = tt.name
+		events := Events(tt.run, tt.anomalies)
+		var triggers []string
+		found := false
+		for _, e := range events {
+			triggers = append(triggers, e.Trigger)
+			found = found || strings.Contains(e.Message, tt.message)
+			if e.Report != tt.name {
+				t.Errorf("%s: event without the report", tt.name)
+			}
+		}
+		if got := strings.Join(triggers, ","); got != tt.triggers {
+			t.Errorf("%s: triggers %s, want %s", tt.name, got, tt.triggers)
+		}
+		if !found {
+			t.Errorf("%s: no message contains %q: %+v", tt.name, tt.message, events)
+		}
+	}
+}
+
+// recorder is a Notifier that records the triggers it receives.
+type recorder struct {
+	triggers []string
+	fail     bool
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Notify(ctx context.Context, event Event) error {
+	r.triggers = append(r.triggers, event.Trigger)
+	if r.fail {
+		return fmt.Errorf("unreachable")
+	}
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+
+	failures := &recorder{}
+	everything := &recorder{fail: true}
+	subscriptions := []Subscription{
+		{Notifier: failures, On: []string{TriggerFailure, TriggerPartialFailure}},
+		{Notifier: everything, On: Triggers()},
+	}
+	events := []Event{{Trigger: TriggerSuccess}, {Trigger: TriggerAnomaly}}
+
+	if failed := Dispatch(context.Background(), subscriptions, events); failed != 2 {
+		t.Errorf("Dispatch() = %d failed deliveries, want 2", failed)
+	}
+	if len(failures.triggers) != 0 {
+		t.Errorf("failure notifier received %v, want nothing", failures.triggers)
+	}
+	if strings.Join(everything.triggers, ",") != "success,anomaly" {
+		t.Errorf("notifier of all triggers received %v, want success and anomaly", everything.triggers)
+	}
+}
+
+func TestNew(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"webhook", Config{Type: "webhook", URL: "https://ntfy.example.com/rates", On: []string{TriggerFailure}}, true},
+		{"unknown type", Config{Type: "pager", On: []string{TriggerFailure}}, false},
+		{"no triggers", Config{Type: "webhook", URL: "https://ntfy.example.com/rates"}, false},
+		{"unknown trigger", Config{Type: "webhook", URL: "https://ntfy.example.com/rates", On: []string{"never"}}, false},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.config); (err == nil) != tt.valid {
+			t.Errorf("%s: New() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
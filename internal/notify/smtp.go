@@ -0,0 +1,176 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultSubject  = "{{.Title}}"
+	defaultMailBody = "{{.Message}}\n"
+)
+
+// SMTP is the notifier that sends a plain text mail. Port 465 uses implicit TLS, other
+// ports upgrade with STARTTLS when the server offers it.
+type SMTP struct {
+	name           string
+	host           string
+	port           int
+	username       string
+	password       string
+	from           string
+	to             []string
+	subject        *template.Template
+	body           *template.Template
+	timeoutSeconds int
+}
+
+// NewSMTP creates a new SMTP notifier with the provided configuration.
+func NewSMTP(config Config) (*SMTP, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("smtp notifier requires a host")
+	}
+	if config.From == "" || len(config.To) == 0 {
+		return nil, fmt.Errorf("smtp notifier requires from and to")
+	}
+
+	subjectText := config.Subject
+	if subjectText == "" {
+		subjectText = defaultSubject
+	}
+	subject, err := parseTemplate("subject", subjectText)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyText := config.Body
+	if bodyText == "" {
+		bodyText = defaultMailBody
+	}
+	body, err := parseTemplate("body", bodyText)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &SMTP{
+		name:           config.Name,
+		host:           config.Host,
+		port:           config.Port,
+		username:       config.Username,
+		password:       config.Password,
+		from:           config.From,
+		to:             config.To,
+		subject:        subject,
+		body:           body,
+		timeoutSeconds: config.TimeoutSeconds,
+	}
+	if n.port == 0 {
+		n.port = 587
+	}
+	return n, nil
+}
+
+// Name returns the name of the notifier.
+func (n *SMTP) Name() string {
+	return n.name
+}
+
+// Notify sends the rendered mail to the recipients.
+func (n *SMTP) Notify(ctx context.Context, event Event) error {
+
+	subject, err := render(n.subject, event)
+	if err != nil {
+		return err
+	}
+	body, err := render(n.body, event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(n.timeoutSeconds)*time.Second)
+	defer cancel()
+
+	address := net.JoinHostPort(n.host, strconv.Itoa(n.port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if n.port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.host})
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to %s: %v", address, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && n.port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %v", err)
+		}
+	}
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	for _, to := range n.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to send mail to %s: %v", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	message := "From: " + n.from + "\r\n" +
+		"To: " + strings.Join(n.to, ", ") + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject)) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	if _, err := w.Write([]byte(message)); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+
+	return client.Quit()
+}
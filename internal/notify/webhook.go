@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultWebhookBody sends the whole event as JSON.
+const defaultWebhookBody = "{{json .}}"
+
+// Webhook is the notifier that sends an HTTP request with a templated body, e.g. to ntfy,
+// Gotify or a Slack-compatible endpoint. The body defaults to the event as JSON.
+// Header values are templates too, e.g. a Title header for ntfy.
+type Webhook struct {
+	name           string
+	url            string
+	method         string
+	headers        map[string]*template.Template
+	body           *template.Template
+	timeoutSeconds int
+	client         *http.Client
+}
+
+// NewWebhook creates a new Webhook notifier with the provided configuration.
+func NewWebhook(config Config) (*Webhook, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook notifier requires a url")
+	}
+
+	text := config.Body
+	if text == "" {
+		text = defaultWebhookBody
+	}
+	body, err := parseTemplate("body", text)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]*template.Template)
+	for k, v := range config.Headers {
+		headers[k], err = parseTemplate("header "+k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n := &Webhook{
+		name:           config.Name,
+		url:            config.URL,
+		method:         strings.ToUpper(config.Method),
+		headers:        headers,
+		body:           body,
+		timeoutSeconds: config.TimeoutSeconds,
+		client:         config.HTTPClient,
+	}
+	if n.method == "" {
+		n.method = http.MethodPost
+	}
+	if n.client == nil {
+		n.client = http.DefaultClient
+	}
+	return n, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Webhook) Name() string {
+	return n.name
+}
+
+// Notify sends the rendered body to the URL.
+func (n *Webhook) Notify(ctx context.Context, event Event) error {
+
+	body, err := render(n.body, event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(n.timeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, n.method, n.url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, t := range n.headers {
+		value, err := render(t, event)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(k, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prune
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+const (
+	// KeepMonthEnd keeps the last stored rate of every month for each pair.
+	KeepMonthEnd = "month-end"
+	// KeepWeekEnd keeps the last stored rate of every ISO week for each pair.
+	KeepWeekEnd = "week-end"
+	// KeepNone keeps no older rates besides those on transaction dates.
+	KeepNone = "none"
+)
+
+// Policy is the retention policy for exchange rates stored in Firefly III.
+type Policy struct {
+	// KeepDays is the number of days, counted back from today, for which every daily rate is kept.
+	KeepDays int `mapstructure:"keep_days"`
+	// Keep selects the rates kept after KeepDays: month-end (default), week-end or none.
+	Keep string `mapstructure:"keep"`
+	// KeepTransactionDates keeps every rate on a date that has a transaction. It is on in
+	// DefaultPolicy, so the rates of booked transactions are not removed unless asked to.
+	KeepTransactionDates bool `mapstructure:"keep_transaction_dates"`
+}
+
+// DefaultPolicy returns the default retention policy: every rate of the last 90 days, the
+// month-end rates before, and the rates on dates that have transactions.
+func DefaultPolicy() Policy {
+	return Policy{KeepDays: 90, Keep: KeepMonthEnd, KeepTransactionDates: true}
+}
+
+// Validate checks the policy and fills in the defaults.
+func (p *Policy) Validate() error {
+	if p.KeepDays < 0 {
+		return fmt.Errorf("keep_days must not be negative, got %d", p.KeepDays)
+	}
+	switch p.Keep {
+	case "":
+		p.Keep = KeepMonthEnd
+	case KeepMonthEnd, KeepWeekEnd, KeepNone:
+	default:
+		return fmt.Errorf("invalid keep %q, expected %q, %q or %q", p.Keep, KeepMonthEnd, KeepWeekEnd, KeepNone)
+	}
+	return nil
+}
+
+// Cutoff returns the first date whose daily rates are all kept.
+func (p Policy) Cutoff(today time.Time) string {
+	return today.AddDate(0, 0, -p.KeepDays+1).Format("2006-01-02")
+}
+
+// Plan returns the rates the policy removes, ordered by date and pair.
+//
+// Parameters:
+//   - rates: all exchange rates stored in Firefly III.
+//   - transactionDates: the dates with transactions, only used with KeepTransactionDates.
+//   - policy: the validated retention policy.
+//   - today: the date the retention period is counted back from.
+//
+// Returns:
+//   - The rates to delete.
+func Plan(rates []firefly.ExchangeRate, transactionDates map[string]bool, policy Policy, today time.Time) []firefly.ExchangeRate {
+
+	cutoff := policy.Cutoff(today)
+
+	// Last stored date of every period for each pair
+	periodEnds := make(map[string]string)
+	if policy.Keep != KeepNone {
+		for _, rate := range rates {
+			key := rate.From + "/" + rate.To + "/" + period(rate.Date, policy.Keep)
+			if rate.Date > periodEnds[key] {
+				periodEnds[key] = rate.Date
+			}
+		}
+	}
+
+	var removed []firefly.ExchangeRate
+	for _, rate := range rates {
+		if rate.Date >= cutoff {
+			continue
+		}
+		if policy.KeepTransactionDates && transactionDates[rate.Date] {
+			continue
+		}
+		if policy.Keep != KeepNone && periodEnds[rate.From+"/"+rate.To+"/"+period(rate.Date, policy.Keep)] == rate.Date {
+			continue
+		}
+		removed = append(removed, rate)
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].Date != removed[j].Date {
+			return removed[i].Date < removed[j].Date
+		}
+		if removed[i].From != removed[j].From {
+			return removed[i].From < removed[j].From
+		}
+		return removed[i].To < removed[j].To
+	})
+	return removed
+}
+
+// Apply deletes the planned rates from Firefly III.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client.
+//   - rates: the rates returned by Plan.
+//
+// Returns:
+//   - The number of deleted rates and an error if a request fails.
+func Apply(ctx context.Context, api *firefly.Api, rates []firefly.ExchangeRate) (int, error) {
+
+	// Deleting by pair and date removes duplicates of the same day at once
+	deleted := make(map[string]bool)
+	count := 0
+	for _, rate := range rates {
+		key := rate.From + "/" + rate.To + "/" + rate.Date
+		if deleted[key] {
+			count++
+			continue
+		}
+		if err := api.DeleteExchangeRate(ctx, rate.From, rate.To, rate.Date); err != nil {
+			return count, err
+		}
+		deleted[key] = true
+		count++
+	}
+	return count, nil
+}
+
+// period returns the month (YYYY-MM) or ISO week (YYYY-Www) of the date.
+func period(date string, keep string) string {
+	if keep == KeepMonthEnd && len(date) >= 7 {
+		return date[:7]
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	year, week := day.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prune
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+func TestPlan(t *testing.T) {
+
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	var rates []firefly.ExchangeRate
+	for _, date := range []string{"2025-01-06", "2025-01-10", "2025-01-13", "2025-01-31", "2025-02-03", "2025-02-28", "2025-03-05"} {
+		rates = append(rates, firefly.ExchangeRate{From: "USD", To: "EUR", Date: date})
+	}
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		dates   map[string]bool
+		removed string
+	}{
+		{"month-end", Policy{KeepDays: 30, Keep: KeepMonthEnd}, nil, "2025-01-06,2025-01-10,2025-01-13,2025-02-03"},
+		{"week-end", Policy{KeepDays: 30, Keep: KeepWeekEnd}, nil, "2025-01-06"},
+		{"none", Policy{KeepDays: 30, Keep: KeepNone}, nil, "2025-01-06,2025-01-10,2025-01-13,2025-01-31,2025-02-03"},
+		{"transaction dates", Policy{KeepDays: 30, Keep: KeepNone, KeepTransactionDates: true}, map[string]bool{"2025-01-13": true}, "2025-01-06,2025-01-10,2025-01-31,2025-02-03"},
+		{"transaction dates off", Policy{KeepDays: 30, Keep: KeepNone}, map[string]bool{"2025-01-13": true}, "2025-01-06,2025-01-10,2025-01-13,2025-01-31,2025-02-03"},
+		{"default keeps transaction dates", func() Policy { p := DefaultPolicy(); p.KeepDays = 30; return p }(), map[string]bool{"2025-01-13": true}, "2025-01-06,2025-01-10,2025-02-03"},
+		{"everything recent", Policy{KeepDays: 365, Keep: KeepNone}, nil, ""},
+	}
+	for _, tt := range tests {
+		var removed []string
+		for _, r := range Plan(rates, tt.dates, tt.policy, today) {
+			removed = append(removed, r.Date)
+		}
+		if got := strings.Join(removed, ","); got != tt.removed {
+			t.Errorf("%s: removed %s, want %s", tt.name, got, tt.removed)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+
+	tests := []struct {
+		policy Policy
+		keep   string
+		valid  bool
+	}{
+		{Policy{KeepDays: 90}, KeepMonthEnd, true},
+		{DefaultPolicy(), KeepMonthEnd, true},
+		{Policy{Keep: KeepWeekEnd}, KeepWeekEnd, true},
+		{Policy{KeepDays: -1}, "", false},
+		{Policy{Keep: "daily"}, "", false},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.policy, err, tt.valid)
+			continue
+		}
+		if tt.valid && tt.policy.Keep != tt.keep {
+			t.Errorf("Validate() keep = %s, want %s", tt.policy.Keep, tt.keep)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+const (
+	// ConflictOverwrite replaces existing rates with the snapshot values.
+	ConflictOverwrite = "overwrite"
+	// ConflictSkip keeps existing rates and only creates missing ones.
+	ConflictSkip = "skip-existing"
+	// ConflictFail aborts the restore before writing anything if any rate exists.
+	ConflictFail = "fail"
+)
+
+// Result counts what a restore did.
+type Result struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+// Restore writes the snapshot rates back to the Firefly III instance.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client.
+//   - s: the snapshot to restore.
+//   - conflict: what to do with rates that already exist, one of ConflictOverwrite, ConflictSkip or ConflictFail.
+//
+// Returns:
+//   - The Result and an error if a conflict is found in ConflictFail mode or a request fails.
+func Restore(ctx context.Context, api *firefly.Api, s Snapshot, conflict string) (Result, error) {
+
+	switch conflict {
+	case ConflictOverwrite, ConflictSkip, ConflictFail:
+	default:
+		return Result{}, fmt.Errorf("unknown conflict mode %q, expected %s, %s or %s", conflict, ConflictOverwrite, ConflictSkip, ConflictFail)
+	}
+
+	if len(s.Rates) == 0 {
+		return Result{}, nil
+	}
+
+	// Only the dates of the snapshot can conflict
+	current, err := api.ListExchangeRates(ctx, s.dateRange())
+	if err != nil {
+		return Result{}, err
+	}
+	existing := make(map[string]firefly.ExchangeRate)
+	for _, r := range current {
+		existing[key(r.Date, r.From, r.To)] = r
+	}
+
+	var conflicts []string
+	for _, r := range s.Rates {
+		if _, ok := existing[key(r.Date, r.From, r.To)]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s/%s on %s", r.From, r.To, r.Date))
+		}
+	}
+	if conflict == ConflictFail && len(conflicts) > 0 {
+		return Result{}, fmt.Errorf("%d rates already exist, e.g. %s", len(conflicts), conflicts[0])
+	}
+
+	var result Result
+	// Missing rates are created in batches per source currency and date
+	batches := make(map[string]map[string]float64)
+	for _, r := range s.Rates {
+		if e, ok := existing[key(r.Date, r.From, r.To)]; ok {
+			if conflict == ConflictSkip || sameRate(e.Rate, r.Rate) {
+				result.Skipped++
+				continue
+			}
+			if err := api.UpdateExchangeRate(ctx, e.ID, r.Date, r.Rate); err != nil {
+				return result, fmt.Errorf("failed to restore rate %s/%s on %s: %v", r.From, r.To, r.Date, err)
+			}
+			result.Updated++
+			continue
+		}
+
+		batch := r.Date + "|" + r.From
+		if batches[batch] == nil {
+			batches[batch] = make(map[string]float64)
+		}
+		batches[batch][r.To] = r.Rate
+	}
+
+	var keys []string
+	for k := range batches {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		date, from, _ := strings.Cut(k, "|")
+		if err := api.SendExchangeRateByDate(ctx, from, batches[k], date); err != nil {
+			return result, fmt.Errorf("failed to restore rates for %s on %s: %v", from, date, err)
+		}
+		result.Created += len(batches[k])
+		slog.Info("Restored rates", "base", from, "date", date, "rates", len(batches[k]))
+	}
+
+	return result, nil
+}
+
+// dateRange returns the range from the first to the last date of the snapshot rates.
+func (s Snapshot) dateRange() firefly.ListOptions {
+	var options firefly.ListOptions
+	for _, r := range s.Rates {
+		if options.Start == "" || r.Date < options.Start {
+			options.Start = r.Date
+		}
+		if r.Date > options.End {
+			options.End = r.Date
+		}
+	}
+	return options
+}
+
+// sameRate compares rates at the precision they are sent to Firefly III with.
+func sameRate(a float64, b float64) bool {
+	return fmt.Sprintf("%.8f", a) == fmt.Sprintf("%.8f", b)
+}
+
+func key(date string, from string, to string) string {
+	return date + "|" + from + "|" + to
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+	"github.com/ewok/ffiii-rate-updater/internal/firefly/fireflytest"
+)
+
+func TestTakeRange(t *testing.T) {
+
+	server := fireflytest.NewServer(
+		fireflytest.Rate{From: "USD", To: "EUR", Date: "2024-12-31", Rate: 0.96},
+		fireflytest.Rate{From: "USD", To: "EUR", Date: "2025-01-02", Rate: 0.97},
+		fireflytest.Rate{From: "USD", To: "EUR", Date: "2025-02-01", Rate: 0.95},
+	)
+	defer server.Close()
+
+	s, err := Take(context.Background(), server.Client(), firefly.ListOptions{Start: "2025-01-01", End: "2025-01-31"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Rates) != 1 || s.Rates[0].Date != "2025-01-02" {
+		t.Errorf("rates = %+v, want only 2025-01-02", s.Rates)
+	}
+	if requests := server.Requests(); len(requests) != 1 || !strings.Contains(requests[0], "start=2025-01-01") || !strings.Contains(requests[0], "end=2025-01-31") {
+		t.Errorf("requests = %v, want one list of the range", requests)
+	}
+}
+
+func TestRestore(t *testing.T) {
+
+	s := Snapshot{Version: Version, Rates: []Rate{
+		{Date: "2025-01-02", From: "USD", To: "EUR", Rate: 0.97},
+		{Date: "2025-01-02", From: "USD", To: "GBP", Rate: 0.80},
+		{Date: "2025-01-03", From: "USD", To: "EUR", Rate: 0.96},
+	}}
+
+	tests := []struct {
+		conflict string
+		want     Result
+		eur      float64
+		valid    bool
+	}{
+		{ConflictOverwrite, Result{Created: 2, Updated: 1}, 0.97, true},
+		{ConflictSkip, Result{Created: 2, Skipped: 1}, 0.90, true},
+		{ConflictFail, Result{}, 0.90, false},
+	}
+	for _, tt := range tests {
+		server := fireflytest.NewServer(
+			fireflytest.Rate{From: "USD", To: "EUR", Date: "2025-01-02", Rate: 0.90},
+			fireflytest.Rate{From: "USD", To: "EUR", Date: "2024-01-02", Rate: 0.91},
+		)
+
+		result, err := Restore(context.Background(), server.Client(), s, tt.conflict)
+		if (err == nil) != tt.valid {
+			t.Fatalf("%s: Restore() = %v, want valid %v", tt.conflict, err, tt.valid)
+		}
+		if result != tt.want {
+			t.Errorf("%s: result %+v, want %+v", tt.conflict, result, tt.want)
+		}
+		if rates := server.Rates(); rates[1].Rate != tt.eur {
+			t.Errorf("%s: USD/EUR on 2025-01-02 = %v, want %v", tt.conflict, rates[1].Rate, tt.eur)
+		}
+
+		// Existing rates are only listed for the dates of the snapshot
+		if list := server.Requests()[0]; !strings.Contains(list, "start=2025-01-02") || !strings.Contains(list, "end=2025-01-03") {
+			t.Errorf("%s: first request %s, want the list of the snapshot dates", tt.conflict, list)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+// Version is the current snapshot file format version.
+const Version = 1
+
+// csvPrefix starts the first line of a CSV snapshot, followed by the version.
+const csvPrefix = "# ffiii-rate-updater snapshot version "
+
+// Rate is a single exchange rate in a snapshot: one From is worth Rate To on Date.
+type Rate struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+// Snapshot is a dump of all exchange rates of a Firefly III instance.
+type Snapshot struct {
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+	// Source is the API URL of the instance the snapshot was taken from.
+	Source string `json:"source"`
+	Rates  []Rate `json:"rates"`
+}
+
+// Take dumps the exchange rates of the Firefly III instance.
+//
+// Parameters:
+//   - ctx: the context that cancels the requests.
+//   - api: the Firefly III client.
+//   - options: the date range of the rates; the zero value dumps all rates.
+//
+// Returns:
+//   - The Snapshot, with rates sorted by date and pair, and an error if the rates cannot be listed.
+func Take(ctx context.Context, api *firefly.Api, options firefly.ListOptions) (Snapshot, error) {
+
+	rates, err := api.ListExchangeRates(ctx, options)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	s := Snapshot{
+		Version:   Version,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Source:    api.Config.ApiUrl,
+	}
+	for _, r := range rates {
+		s.Rates = append(s.Rates, Rate{Date: r.Date, From: r.From, To: r.To, Rate: r.Rate})
+	}
+
+	sort.Slice(s.Rates, func(i, j int) bool {
+		a, b := s.Rates[i], s.Rates[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	return s, nil
+}
+
+// Write saves the snapshot as CSV when the path ends with .csv, and as JSON otherwise.
+// The file is written next to the path and renamed into place, so an existing snapshot is
+// never left half-overwritten.
+func Write(path string, s Snapshot) error {
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	err = encode(f, path, s)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// encode writes the snapshot in the format of the path.
+func encode(w io.Writer, path string, s Snapshot) error {
+
+	if strings.ToLower(filepath.Ext(path)) != ".csv" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "%s%d\n", csvPrefix, s.Version)
+	fmt.Fprintf(buffered, "# created_at %s\n", s.CreatedAt)
+	fmt.Fprintf(buffered, "# source %s\n", s.Source)
+
+	writer := csv.NewWriter(buffered)
+	writer.Write([]string{"date", "from", "to", "rate"})
+	for _, r := range s.Rates {
+		writer.Write([]string{r.Date, r.From, r.To, strconv.FormatFloat(r.Rate, 'f', -1, 64)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// Read loads a snapshot written by Write.
+func Read(path string) (Snapshot, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+
+	var s Snapshot
+	if strings.ToLower(filepath.Ext(path)) != ".csv" {
+		if err := json.NewDecoder(f).Decode(&s); err != nil {
+			return Snapshot{}, fmt.Errorf("failed to parse snapshot: %v", err)
+		}
+	} else {
+		s, err = readCSV(f)
+		if err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	if s.Version < 1 || s.Version > Version {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	return s, nil
+}
+
+func readCSV(r io.Reader) (Snapshot, error) {
+
+	reader := bufio.NewReader(r)
+
+	var s Snapshot
+	first, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(first, csvPrefix) {
+		return Snapshot{}, fmt.Errorf("not a snapshot file")
+	}
+	s.Version, err = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(first, csvPrefix)))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("invalid snapshot version: %v", err)
+	}
+
+	// Remaining comment lines hold the metadata
+	for {
+		peek, err := reader.Peek(1)
+		if err != nil || peek[0] != '#' {
+			break
+		}
+		line, _ := reader.ReadString('\n')
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if v, ok := strings.CutPrefix(line, "created_at "); ok {
+			s.CreatedAt = v
+		}
+		if v, ok := strings.CutPrefix(line, "source "); ok {
+			s.Source = v
+		}
+	}
+
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse snapshot: %v", err)
+	}
+	for i, record := range records {
+		if i == 0 || len(record) != 4 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("invalid rate %q on line %d", record[3], i+1)
+		}
+		s.Rates = append(s.Rates, Rate{Date: record[0], From: record[1], To: record[2], Rate: rate})
+	}
+	return s, nil
+}
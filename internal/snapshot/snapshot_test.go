@@ -0,0 +1,75 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+
+	s := Snapshot{
+		Version:   Version,
+		CreatedAt: "2025-01-03T10:00:00Z",
+		Source:    "https://firefly.example.com/api/v1",
+		Rates: []Rate{
+			{Date: "2025-01-02", From: "USD", To: "EUR", Rate: 0.97},
+			{Date: "2025-01-02", From: "USD", To: "KGS", Rate: 87.45},
+		},
+	}
+
+	for _, name := range []string{"rates.json", "rates.csv"} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, name)
+
+		// An existing snapshot is replaced
+		if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Write(path, s); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		got, err := Read(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, s) {
+			t.Errorf("%s: read %+v, want %+v", name, got, s)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: %d files in the directory, want no temporary file left", name, len(entries))
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o644 {
+			t.Errorf("%s: mode %v, want 0644", name, info.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteMissingDirectory(t *testing.T) {
+
+	if err := Write(filepath.Join(t.TempDir(), "missing", "rates.json"), Snapshot{Version: Version}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package textdist
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"EUR", "", 3},
+		{"", "date", 4},
+		{"EUR", "EUR", 0},
+		{"EUR", "EURO", 1},
+		{"GPB", "GBP", 2},
+		{"USD", "AUD", 2},
+		{"XUD", "AUD", 1},
+		{"kitten", "sitting", 3},
+		{"currencies", "currencies", 0},
+		{"curencies", "currencies", 1},
+		{"provder", "provider", 1},
+		{"api_url", "api_key", 3},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
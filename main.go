@@ -15,7 +15,7 @@ limitations under the License.
 */
 package main
 
-import "ffiii-rate-updater/cmd"
+import "github.com/ewok/ffiii-rate-updater/cmd"
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,168 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package updater
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly"
+)
+
+// ClientConfig holds the configuration of a Client.
+type ClientConfig struct {
+	// ApiKey is the personal access token used for authentication.
+	ApiKey string
+	// ApiUrl is the base URL of the Firefly III API, e.g. "https://firefly.example.com/api/v1".
+	ApiUrl string
+	// TimeoutSeconds specifies the timeout for API requests in seconds.
+	TimeoutSeconds int
+	// HTTPClient is the client used for all requests. When nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// ExchangeRate is an exchange rate stored in Firefly III.
+type ExchangeRate struct {
+	// ID is the Firefly III identifier of the rate.
+	ID string
+	// From and To are the currency codes of the pair.
+	From string
+	To   string
+	// Date is the date of the rate (YYYY-MM-DD).
+	Date string
+	// Rate is the value of one From in To.
+	Rate float64
+	// CreatedAt and UpdatedAt are the times the rate was stored and last changed.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ListOptions filters and pages the exchange rate lists of a Client.
+type ListOptions struct {
+	// Start and End limit the rates to a date range (YYYY-MM-DD, inclusive); empty leaves that side open.
+	Start string
+	End   string
+	// Page is the page returned by ListExchangeRatesPage; 0 means the first page.
+	Page int
+	// Limit is the page size; 0 means the server default.
+	Limit int
+}
+
+// Pagination describes a page of a list.
+type Pagination struct {
+	// Total is the number of rates on all pages, Count the number on this page.
+	Total int
+	Count int
+	// PerPage is the page size.
+	PerPage     int
+	CurrentPage int
+	TotalPages  int
+}
+
+// ExchangeRatePage is one page of exchange rates returned by Client.ListExchangeRatesPage.
+type ExchangeRatePage struct {
+	Rates      []ExchangeRate
+	Pagination Pagination
+}
+
+// Client is a Firefly III API client for the exchange-rate resource.
+type Client struct {
+	api *firefly.Api
+}
+
+// NewClient creates a Firefly III client.
+func NewClient(config ClientConfig) *Client {
+	return &Client{api: firefly.NewApi(firefly.ApiConfig(config))}
+}
+
+// IsNotFound reports whether the error of a Client method is a 404 response.
+func IsNotFound(err error) bool {
+	return firefly.IsNotFound(err)
+}
+
+// SendExchangeRates stores the rates of one base currency on the date, creating or replacing them.
+//
+// Parameters:
+//   - ctx: the context that cancels the request.
+//   - from: the base currency code.
+//   - rates: the target currency codes mapped to the value of one from.
+//   - date: the date of the rates (YYYY-MM-DD); empty means today.
+//
+// Returns:
+//   - An error if the operation fails; otherwise, nil.
+func (c *Client) SendExchangeRates(ctx context.Context, from string, rates map[string]float64, date string) error {
+	return c.api.SendExchangeRateByDate(ctx, from, rates, date)
+}
+
+// ListExchangeRates returns the exchange rates stored in Firefly III, reading every page.
+func (c *Client) ListExchangeRates(ctx context.Context, options ListOptions) ([]ExchangeRate, error) {
+	rates, err := c.api.ListExchangeRates(ctx, firefly.ListOptions(options))
+	return newExchangeRates(rates), err
+}
+
+// ListExchangeRatesPage returns one page of the exchange rates stored in Firefly III.
+// Firefly III versions that ignore start and end return rates outside the range.
+func (c *Client) ListExchangeRatesPage(ctx context.Context, options ListOptions) (ExchangeRatePage, error) {
+	page, err := c.api.ListExchangeRatesPage(ctx, firefly.ListOptions(options))
+	return ExchangeRatePage{Rates: newExchangeRates(page.Rates), Pagination: Pagination(page.Pagination)}, err
+}
+
+// ListExchangeRatesByPair returns the exchange rates of the pair, reading every page.
+func (c *Client) ListExchangeRatesByPair(ctx context.Context, from string, to string, options ListOptions) ([]ExchangeRate, error) {
+	rates, err := c.api.ListExchangeRatesByPair(ctx, from, to, firefly.ListOptions(options))
+	return newExchangeRates(rates), err
+}
+
+// GetExchangeRate returns the exchange rate of the pair on the date and whether it exists.
+func (c *Client) GetExchangeRate(ctx context.Context, from string, to string, date string) (ExchangeRate, bool, error) {
+	rate, found, err := c.api.GetExchangeRate(ctx, from, to, date)
+	return ExchangeRate(rate), found, err
+}
+
+// GetExchangeRateById returns the exchange rate with the Firefly III identifier.
+func (c *Client) GetExchangeRateById(ctx context.Context, id string) (ExchangeRate, error) {
+	rate, err := c.api.GetExchangeRateById(ctx, id)
+	return ExchangeRate(rate), err
+}
+
+// UpdateExchangeRate changes the date and value of an existing exchange rate.
+func (c *Client) UpdateExchangeRate(ctx context.Context, id string, date string, rate float64) error {
+	return c.api.UpdateExchangeRate(ctx, id, date, rate)
+}
+
+// DeleteExchangeRate deletes the exchange rate of the pair on the date. IsNotFound reports a missing rate.
+func (c *Client) DeleteExchangeRate(ctx context.Context, from string, to string, date string) error {
+	return c.api.DeleteExchangeRate(ctx, from, to, date)
+}
+
+// DeleteExchangeRateById deletes the exchange rate with the Firefly III identifier.
+func (c *Client) DeleteExchangeRateById(ctx context.Context, id string) error {
+	return c.api.DeleteExchangeRateById(ctx, id)
+}
+
+// DeleteExchangeRatesByPair deletes the exchange rates of the pair on all dates.
+func (c *Client) DeleteExchangeRatesByPair(ctx context.Context, from string, to string) error {
+	return c.api.DeleteExchangeRatesByPair(ctx, from, to)
+}
+
+func newExchangeRates(rates []firefly.ExchangeRate) []ExchangeRate {
+	result := make([]ExchangeRate, len(rates))
+	for i, r := range rates {
+		result[i] = ExchangeRate(r)
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package updater is the public API of ffiii-rate-updater for embedding it in other programs.
+//
+// Run does what the update command does: it fetches the rates of the currencies from a
+// provider, applies overrides and markups, and sends them to Firefly III.
+//
+//	provider, err := updater.NewProvider("ecb", updater.ProviderConfig{})
+//	if err != nil {
+//		return err
+//	}
+//	report, err := updater.Run(ctx, updater.Options{
+//		Client:     updater.NewClient(updater.ClientConfig{ApiUrl: "https://firefly.example.com/api/v1", ApiKey: key, TimeoutSeconds: 10}),
+//		Provider:   provider,
+//		Currencies: []string{"EUR", "USD", "GBP"},
+//	})
+//
+// Providers, the rate set and the Firefly III client can also be used on their own.
+// Everything exported by this package is covered by the compatibility promise of the
+// module. Its types are its own and are converted at the package boundary, so the
+// internal packages the tool is built on can change without changing them.
+package updater
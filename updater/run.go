@@ -0,0 +1,386 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package updater
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+	"github.com/ewok/ffiii-rate-updater/internal/journal"
+	"github.com/ewok/ffiii-rate-updater/internal/limit"
+)
+
+// Options configures a Run.
+type Options struct {
+	// Client is the Firefly III instance the rates are sent to.
+	Client *Client
+	// Provider is the source of the exchange rates.
+	Provider Provider
+	// Currencies are the Firefly III currency codes; rates are sent between every pair.
+	Currencies []string
+	// Date is the date (YYYY-MM-DD) of the rates; empty means "latest".
+	Date string
+	// Mappings map Firefly III currency codes to provider codes.
+	Mappings []CurrencyMapping
+	// Overrides are manual rates and pegs merged into the fetched rates.
+	Overrides []Override
+	// Markups are spreads or fees applied on top of the provider rates.
+	Markups []Markup
+	// Aggregate, when set, sends period-average rates instead of daily rates.
+	Aggregate *AggregateConfig
+	// FetchConcurrency is the maximum number of provider requests at the same time; 0 means 1.
+	FetchConcurrency int
+	// SendConcurrency is the maximum number of Firefly III writes at the same time; 0 means 1.
+	SendConcurrency int
+	// JournalDir is the directory of the run journal used to revert runs; empty disables the journal.
+	JournalDir string
+}
+
+// Batch statuses.
+const (
+	// BatchPending is the status of a batch that has not been sent yet.
+	BatchPending = "pending"
+	// BatchSent is the status of a batch Firefly III accepted.
+	BatchSent = "sent"
+	// BatchFailed is the status of a batch Firefly III or the network rejected.
+	BatchFailed = "failed"
+	// BatchSkipped is the status of a batch that was not sent, see Batch.Error for the reason.
+	BatchSkipped = "skipped"
+)
+
+// SkippedRate is a rate of a batch that could not be sent.
+type SkippedRate struct {
+	// To is the target currency as it is spelled in Firefly III.
+	To string `json:"to"`
+	// Reason says why the rate was skipped.
+	Reason string `json:"reason"`
+}
+
+// BatchRate is one rate of a batch with where its value comes from.
+type BatchRate struct {
+	// To is the target currency as it is spelled in Firefly III.
+	To string `json:"to"`
+	// Rate is the value of one From in To as sent to Firefly III.
+	Rate float64 `json:"rate"`
+	// Override is true when the rate comes from the configured overrides or pegs.
+	Override bool `json:"override"`
+	// Adjusted is true when a markup was applied; Raw is then the rate before the markup.
+	Adjusted bool    `json:"adjusted"`
+	Raw      float64 `json:"raw,omitempty"`
+	// Days is the number of daily rates of a period average, 0 for daily rates.
+	Days int `json:"days,omitempty"`
+}
+
+// Coverage lists the days of the period a period average is based on.
+type Coverage struct {
+	// Start and End are the first and last day of the period.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Days are the days with rates.
+	Days []string `json:"days"`
+	// Unpublished are the days the provider published no rates for, e.g. weekends and holidays.
+	Unpublished []string `json:"unpublished"`
+	// Missing are the past days without rates; Pending the days after today.
+	Missing []string `json:"missing"`
+	Pending []string `json:"pending"`
+	// Complete is true when every day of the period has rates or is unpublished.
+	Complete bool `json:"complete"`
+}
+
+// Batch is the set of rates of one base currency sent in one request.
+type Batch struct {
+	// From is the base currency as it is spelled in Firefly III.
+	From string `json:"from"`
+	// Date is the date of the rates.
+	Date string `json:"date"`
+	// Provider is the provider the rates came from, or "overrides" when every rate is configured.
+	Provider string `json:"provider"`
+	// Source is where the provider served the rates from, e.g. a mirror URL or "cache"; empty when unknown.
+	Source string `json:"source,omitempty"`
+	// Rates maps the target currencies to the value of one From.
+	Rates map[string]float64 `json:"rates"`
+	// Details lists the rates in the order of the currencies, marking overrides and markups.
+	Details []BatchRate `json:"details"`
+	// Skipped are the target currencies without a rate.
+	Skipped []SkippedRate `json:"skipped,omitempty"`
+	// Status is BatchPending, BatchSent, BatchFailed or BatchSkipped.
+	Status string `json:"status"`
+	// Error is the reason the batch failed or was skipped.
+	Error string `json:"error,omitempty"`
+	// HTTPStatus is the status of the Firefly III response, 0 when none was received.
+	HTTPStatus int `json:"http_status,omitempty"`
+	// SendMillis is how long sending the batch took.
+	SendMillis int64 `json:"send_ms"`
+}
+
+// Totals counts the rates of a run.
+type Totals struct {
+	// Fetched is the number of rates available for the batches.
+	Fetched int `json:"fetched"`
+	// Sent is the number of rates Firefly III accepted.
+	Sent int `json:"sent"`
+	// Skipped is the number of rates that were not sent, including missing rates.
+	Skipped int `json:"skipped"`
+	// Failed is the number of rates of failed batches.
+	Failed int `json:"failed"`
+}
+
+// Report describes what a Run did. It is meant to be serialised, e.g. for automation.
+type Report struct {
+	// RunID identifies the run in the journal; empty when the journal is disabled or nothing was sent.
+	RunID string `json:"run_id,omitempty"`
+	// Provider is the name of the provider the rates came from.
+	Provider string `json:"provider"`
+	// RequestedDate is the date of Options, "latest" when it was empty.
+	RequestedDate string `json:"requested_date"`
+	// Aggregate is the aggregation period, empty for daily rates.
+	Aggregate string `json:"aggregate,omitempty"`
+	// Coverage lists the days the period averages are based on, nil for daily rates.
+	Coverage *Coverage `json:"coverage,omitempty"`
+	// Date is the date of the rates as resolved by the provider.
+	Date string `json:"date"`
+	// Dates are the distinct dates of the batches, sorted.
+	Dates []string `json:"dates"`
+	// StartedAt and FinishedAt bound the run.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// FetchMillis is how long fetching the rates took, SendMillis how long sending them took.
+	FetchMillis int64 `json:"fetch_ms"`
+	SendMillis  int64 `json:"send_ms"`
+	// Totals counts the rates of the batches.
+	Totals Totals `json:"totals"`
+	// Batches are the batches of the run, in the order of the currencies.
+	Batches []Batch `json:"batches"`
+	// Error is the error the run returned, empty on success.
+	Error string `json:"error,omitempty"`
+}
+
+// SentRates returns the number of rates Firefly III accepted.
+func (r Report) SentRates() int {
+	sent := 0
+	for _, b := range r.Batches {
+		if b.Status == BatchSent {
+			sent += len(b.Rates)
+		}
+	}
+	return sent
+}
+
+// finish fills the dates, totals and end time of the report and records the error.
+func (r *Report) finish(err error) {
+
+	dates := make(map[string]bool)
+	r.Dates = []string{}
+	r.Totals = Totals{}
+	for i := range r.Batches {
+		b := &r.Batches[i]
+		if b.Status == BatchPending {
+			b.Status = BatchSkipped
+			b.Error = "not attempted"
+		}
+		if b.Date != "" && !dates[b.Date] {
+			dates[b.Date] = true
+			r.Dates = append(r.Dates, b.Date)
+		}
+
+		r.Totals.Fetched += len(b.Rates)
+		r.Totals.Skipped += len(b.Skipped)
+		switch b.Status {
+		case BatchSent:
+			r.Totals.Sent += len(b.Rates)
+		case BatchFailed:
+			r.Totals.Failed += len(b.Rates)
+		default:
+			r.Totals.Skipped += len(b.Rates)
+		}
+	}
+	sort.Strings(r.Dates)
+
+	r.FinishedAt = time.Now()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// Run fetches the rates between the currencies and sends them to Firefly III, one batch per
+// base currency. Sending stops at the first failed batch; the report still lists every batch
+// and is filled as far as the run got, also when an error is returned.
+//
+// Parameters:
+//   - ctx: the context that cancels the run.
+//   - options: what to fetch and where to send it.
+//
+// Returns:
+//   - The Report and an error if the options are invalid, the rates cannot be fetched or a batch fails.
+func Run(ctx context.Context, options Options) (Report, error) {
+
+	report := Report{
+		RequestedDate: options.Date,
+		StartedAt:     time.Now(),
+		Batches:       []Batch{},
+	}
+	if report.RequestedDate == "" {
+		report.RequestedDate = "latest"
+	}
+	if options.Aggregate != nil {
+		report.Aggregate = options.Aggregate.Period
+	}
+
+	fail := func(err error) (Report, error) {
+		report.finish(err)
+		return report, err
+	}
+
+	if options.Client == nil {
+		return fail(fmt.Errorf("no Firefly III client"))
+	}
+	if options.Provider == nil {
+		return fail(fmt.Errorf("no provider"))
+	}
+	report.Provider = options.Provider.Name()
+	if len(options.Currencies) < 2 {
+		return fail(fmt.Errorf("please provide at least two currencies to fetch exchange rates"))
+	}
+
+	provider := exchangeProvider(options.Provider)
+	mappings := exchangeMappings(options.Mappings)
+	overrides := exchangeOverrides(options.Overrides)
+
+	var rateSet *exchange.Api
+	var err error
+	if options.Aggregate != nil {
+		rateSet, err = exchange.NewAggregateApi(ctx, provider, options.Currencies, options.Date, mappings, overrides, exchange.AggregateConfig(*options.Aggregate), options.FetchConcurrency)
+	} else {
+		rateSet, err = exchange.NewApi(ctx, provider, options.Currencies, options.Date, mappings, overrides, options.FetchConcurrency)
+	}
+	report.FetchMillis = time.Since(report.StartedAt).Milliseconds()
+	if err != nil {
+		return fail(fmt.Errorf("failed to initialize exchange API: %v", err))
+	}
+
+	if err := rateSet.ApplyMarkups(exchangeMarkups(options.Markups)); err != nil {
+		return fail(fmt.Errorf("failed to apply markups: %v", err))
+	}
+
+	report.Date = rateSet.Date
+	report.Coverage = newCoverage(rateSet.Coverage)
+	report.Batches = batches(rateSet, options.Currencies, report.Provider)
+
+	client := options.Client.api
+	run := journal.NewRun("update", client.Config.ApiUrl)
+	sendStart := time.Now()
+	err = limit.Run(ctx, options.SendConcurrency, len(report.Batches), func(ctx context.Context, i int) error {
+		b := &report.Batches[i]
+		if b.Status != BatchPending {
+			return nil
+		}
+
+		// The journal looks up every rate before sending, which is only worth it when it is kept
+		start := time.Now()
+		var status int
+		var err error
+		if options.JournalDir != "" {
+			status, err = run.SendExchangeRateByDate(ctx, client, b.From, b.Rates, b.Date)
+		} else {
+			status, err = client.PostExchangeRatesByDate(ctx, b.From, b.Rates, b.Date)
+		}
+		b.SendMillis = time.Since(start).Milliseconds()
+		b.HTTPStatus = status
+		if err != nil {
+			b.Status = BatchFailed
+			b.Error = err.Error()
+			return fmt.Errorf("error sending batch rates for %s: %v", b.From, err)
+		}
+		b.Status = BatchSent
+		return nil
+	})
+	report.SendMillis = time.Since(sendStart).Milliseconds()
+	for _, b := range report.Batches {
+		if b.Status == BatchSent {
+			slog.Info("Sent batch exchange rates", "base", b.From, "date", b.Date, "rates", len(b.Rates), "provider", b.Provider)
+		}
+	}
+
+	if options.JournalDir != "" && len(run.Entries) > 0 {
+		if err := journal.Save(options.JournalDir, run); err != nil {
+			slog.Warn("Failed to save run journal", "error", err)
+		} else {
+			report.RunID = run.ID
+			slog.Info("Recorded run", "run", run.ID, "rates", len(run.Entries))
+		}
+	}
+
+	report.finish(err)
+	return report, err
+}
+
+// batches builds one batch per base currency, using Firefly codes as they are spelled in currency_map.
+// Batches without any rate are skipped.
+func batches(rateSet *exchange.Api, currencies []string, provider string) []Batch {
+
+	var result []Batch
+	for i := range currencies {
+		from := rateSet.Mapping.Currency(currencies[i]).String()
+		b := Batch{From: from, Provider: "overrides", Rates: make(map[string]float64), Details: []BatchRate{}, Status: BatchPending}
+
+		for j := range currencies {
+			if i == j {
+				continue
+			}
+			to := rateSet.Mapping.Currency(currencies[j]).String()
+			rate, err := rateSet.GetRate(from, to)
+			if err != nil {
+				slog.Warn("Rate not found", "pair", from+"/"+to, "error", err)
+				b.Skipped = append(b.Skipped, SkippedRate{To: to, Reason: err.Error()})
+				continue
+			}
+			b.Rates[to] = rate.Value
+			detail := BatchRate{To: to, Rate: rate.Value, Override: rate.Override, Adjusted: rate.Adjusted, Days: rate.Days}
+			if rate.Adjusted {
+				detail.Raw = rate.Raw
+			}
+			b.Details = append(b.Details, detail)
+			if rate.Override {
+				slog.Info("Using override", "pair", from+"/"+to, "date", rate.Date, "rate", rate.Value)
+			} else {
+				b.Provider = provider
+			}
+			if rate.Adjusted {
+				slog.Info("Applied markup", "pair", from+"/"+to, "date", rate.Date, "raw", rate.Raw, "rate", rate.Value)
+			}
+			if b.Source == "" {
+				b.Source = rate.Source
+			}
+			// if not set yet, set the date
+			if b.Date == "" {
+				b.Date = rate.Date
+			}
+		}
+
+		if len(b.Rates) == 0 {
+			b.Provider = provider
+			b.Status = BatchSkipped
+			b.Error = "no rates"
+		}
+
+		result = append(result, b)
+	}
+	return result
+}
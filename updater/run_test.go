@@ -0,0 +1,176 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package updater
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/firefly/fireflytest"
+)
+
+// staticProvider serves fixed rates, keyed by lower-case base currency.
+type staticProvider map[string]map[string]float64
+
+func (p staticProvider) Name() string { return "static" }
+
+func (p staticProvider) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+	rates, ok := p[strings.ToLower(currency)]
+	if !ok {
+		return ApiResponse{}, fmt.Errorf("no rates for %s", currency)
+	}
+	return ApiResponse{Date: "2025-01-02", Rates: rates}, nil
+}
+
+func (p staticProvider) Catalogue(ctx context.Context) (Catalogue, error) {
+	return nil, fmt.Errorf("no catalogue")
+}
+
+func TestBatchDetails(t *testing.T) {
+
+	provider := staticProvider{
+		"usd": {"eur": 0.9},
+		"eur": {"usd": 1.1},
+		"kgs": {},
+	}
+	currencies := []string{"USD", "EUR", "KGS"}
+	overrides := []Override{{From: "USD", To: "KGS", Rate: 87.5}}
+
+	rateSet, err := NewRateSet(context.Background(), provider, currencies, "2025-01-02", nil, overrides, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rateSet.ApplyMarkups([]Markup{{From: "EUR", To: "USD", Percent: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := batches(rateSet.api, currencies, provider.Name())
+	if len(result) != 3 {
+		t.Fatalf("got %d batches, want 3", len(result))
+	}
+
+	tests := []struct {
+		from     string
+		to       string
+		rate     float64
+		override bool
+		adjusted bool
+		raw      float64
+	}{
+		{"USD", "EUR", 1 / (1.1 * 1.01), false, true, 0.9},
+		{"USD", "KGS", 87.5, true, false, 0},
+		{"EUR", "USD", 1.1 * 1.01, false, true, 1.1},
+		{"KGS", "USD", 1 / 87.5, true, false, 0},
+	}
+	for _, tt := range tests {
+		var found *BatchRate
+		for _, b := range result {
+			if b.From != tt.from {
+				continue
+			}
+			for i := range b.Details {
+				if b.Details[i].To == tt.to {
+					found = &b.Details[i]
+				}
+			}
+			if found != nil && b.Rates[tt.to] != found.Rate {
+				t.Errorf("%s/%s: details rate %v differs from rates %v", tt.from, tt.to, found.Rate, b.Rates[tt.to])
+			}
+		}
+		if found == nil {
+			t.Errorf("%s/%s: missing from details", tt.from, tt.to)
+			continue
+		}
+		if math.Abs(found.Rate-tt.rate) > 1e-12 || found.Override != tt.override || found.Adjusted != tt.adjusted || math.Abs(found.Raw-tt.raw) > 1e-12 {
+			t.Errorf("%s/%s: got %+v, want rate %v override %v adjusted %v raw %v", tt.from, tt.to, *found, tt.rate, tt.override, tt.adjusted, tt.raw)
+		}
+	}
+
+	// KGS/EUR has no rate: it is skipped, not listed, and the batch only holds overrides
+	for _, b := range result {
+		if b.From != "KGS" {
+			continue
+		}
+		if b.Provider != "overrides" {
+			t.Errorf("KGS: provider = %q, want overrides", b.Provider)
+		}
+		if len(b.Skipped) != 1 || b.Skipped[0].To != "EUR" {
+			t.Errorf("KGS: skipped = %+v, want EUR", b.Skipped)
+		}
+	}
+}
+
+func TestRunJournal(t *testing.T) {
+
+	provider := staticProvider{
+		"usd": {"eur": 0.9, "gbp": 0.8},
+		"eur": {"usd": 1.1, "gbp": 0.85},
+		"gbp": {"usd": 1.25, "eur": 1.2},
+	}
+
+	tests := []struct {
+		name    string
+		journal bool
+		lookups int
+	}{
+		{"journal disabled", false, 0},
+		{"journal enabled", true, 6},
+	}
+	for _, tt := range tests {
+		server := fireflytest.NewServer()
+		dir := ""
+		if tt.journal {
+			dir = t.TempDir()
+		}
+
+		report, err := Run(context.Background(), Options{
+			Client:     &Client{api: server.Client()},
+			Provider:   provider,
+			Currencies: []string{"USD", "EUR", "GBP"},
+			Date:       "2025-01-02",
+			JournalDir: dir,
+		})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		lookups := 0
+		for _, r := range server.Requests() {
+			if strings.HasPrefix(r, "GET ") {
+				lookups++
+			}
+		}
+		if lookups != tt.lookups {
+			t.Errorf("%s: %d lookups, want %d", tt.name, lookups, tt.lookups)
+		}
+		if len(server.Rates()) != 6 || report.Totals.Sent != 6 {
+			t.Errorf("%s: stored %d rates and sent %d, want 6", tt.name, len(server.Rates()), report.Totals.Sent)
+		}
+		if (report.RunID != "") != tt.journal {
+			t.Errorf("%s: run ID %q", tt.name, report.RunID)
+		}
+		if tt.journal {
+			if _, err := os.Stat(dir + "/" + report.RunID + ".json"); err != nil {
+				t.Errorf("%s: journal not saved: %v", tt.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package updater
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+)
+
+// The types below belong to the updater package. They are converted to and from the types
+// of the internal packages at the package boundary, so the internal packages can change
+// without changing them.
+
+// Provider is a source of exchange rates. Implement it to plug in your own source.
+type Provider interface {
+	// Name returns the provider name, used in currency mappings and in the report.
+	Name() string
+	// FetchRates returns the rates of the currency against all other currencies the provider
+	// knows on the date (YYYY-MM-DD, or "latest"). Currency codes are the provider's codes.
+	// The request is cancelled with ctx.
+	FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error)
+	// Catalogue returns the currencies supported by the provider. Return ErrNoCatalogue
+	// when the provider cannot list them; the currencies are then not validated.
+	Catalogue(ctx context.Context) (Catalogue, error)
+}
+
+// ErrNoCatalogue is returned by Provider.Catalogue when the provider cannot list its currencies.
+var ErrNoCatalogue = exchange.ErrNoCatalogue
+
+// CurrencySelector is implemented by providers that need all requested currencies up front.
+type CurrencySelector interface {
+	// SelectCurrencies receives the provider codes of the requested currencies.
+	SelectCurrencies(codes []string)
+}
+
+// DatedCatalogue is implemented by providers whose currencies change over time.
+type DatedCatalogue interface {
+	// CatalogueOn returns the currencies supported on the date (YYYY-MM-DD).
+	CatalogueOn(ctx context.Context, date string) (Catalogue, error)
+}
+
+// ProviderConfig holds the settings of a built-in provider. Providers ignore the settings they do not use.
+type ProviderConfig struct {
+	// URL replaces the default base URL of the provider, e.g. to point it at a mirror or a mock.
+	URL string
+	// FallbackURL is the base URL tried when URL fails.
+	FallbackURL string
+	// TimeoutSeconds specifies the timeout for provider requests in seconds.
+	TimeoutSeconds int
+	// Timezone replaces the time zone a central bank publishes its rates in (e.g. "Asia/Bishkek").
+	Timezone string
+	// Headers are additional HTTP headers sent with every request, e.g. an API key.
+	Headers map[string]string
+	// Coins maps currency codes to coin IDs of a crypto provider (e.g. BTC: bitcoin).
+	Coins map[string]string
+	// TimeOfDay selects which daily crypto price is used for a date: "close" (default) or "open".
+	TimeOfDay string
+	// Command is the command and its arguments run by the exec provider.
+	Command []string
+	// Path is the CSV or JSON file, or the directory of per-date files, read by the file provider.
+	Path string
+	// Holidays lists non-business days (YYYY-MM-DD) without official rates, in addition to weekends.
+	Holidays []string
+	// HTTPClient is the client used for all requests. When nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// ApiResponse is the result of Provider.FetchRates: the date and the rates of one base currency.
+type ApiResponse struct {
+	// Date is the date of the rates as published by the provider (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Rates maps the provider codes of the other currencies to the value of one base currency.
+	Rates map[string]float64 `json:"rates"`
+	// Source is where the rates were served from, e.g. a mirror URL; informational only.
+	Source string `json:"source,omitempty"`
+}
+
+// Catalogue holds the currencies supported by a provider, the upper-case codes mapped to their names.
+type Catalogue map[string]string
+
+// Rate is the rate of one pair of a RateSet.
+type Rate struct {
+	// From and To are the currencies of the pair as they are spelled in Firefly III.
+	From string
+	To   string
+	// Date is the date of the rate.
+	Date string
+	// Value is the value of one From in To.
+	Value float64
+	// Override is true when the rate comes from the overrides or pegs rather than the provider.
+	Override bool
+	// Adjusted is true when a markup has been applied, Raw then holds the rate before the markup.
+	Adjusted bool
+	Raw      float64
+	// Source is where the provider served the rate from, see ApiResponse.Source.
+	Source string
+	// Days is the number of daily rates of a period average, 0 for daily rates.
+	Days int
+}
+
+// RateSet holds the rates between a set of currencies on one date.
+type RateSet struct {
+	// Date is the date of the rates as reported by the provider.
+	Date string
+	// Rates are the rates between every pair of the currencies, both directions.
+	Rates []Rate
+	// Coverage lists the days a period average is based on, nil for daily rates.
+	Coverage *Coverage
+
+	api *exchange.Api
+}
+
+// GetRate returns the rate of one From in To.
+//
+// Parameters:
+//   - from: the source currency code as it is defined in Firefly III.
+//   - to: the target currency code as it is defined in Firefly III.
+//
+// Returns:
+//   - The Rate and an error if the rate set has no rate for the pair.
+func (s *RateSet) GetRate(from string, to string) (Rate, error) {
+	rate, err := s.api.GetRate(from, to)
+	if err != nil {
+		return Rate{}, err
+	}
+	return newRate(rate), nil
+}
+
+// ApplyMarkups applies the spreads and fees to the rates of the set.
+//
+// Parameters:
+//   - markups: the markups, applied in order.
+//
+// Returns:
+//   - An error if a markup is invalid.
+func (s *RateSet) ApplyMarkups(markups []Markup) error {
+	if err := s.api.ApplyMarkups(exchangeMarkups(markups)); err != nil {
+		return err
+	}
+	s.Rates = newRates(s.api.Rates)
+	return nil
+}
+
+// CurrencyMapping maps a Firefly III currency code to the code used by a provider.
+type CurrencyMapping struct {
+	// Firefly is the currency code as it is configured in Firefly III (e.g. "GOLD").
+	Firefly string
+	// Provider is the name of the provider the mapping applies to. Empty means all providers.
+	Provider string
+	// Code is the currency code used by the provider (e.g. "xau").
+	Code string
+	// Multiplier is the number of Firefly units in one provider unit; zero means 1.
+	Multiplier float64
+}
+
+// Override is a manual rate or peg merged into the fetched rates. With To it sets a fixed
+// rate for From/To, with Peg it derives the rates of From from the rates of Peg.
+type Override struct {
+	From string
+	To   string
+	Peg  string
+	// Rate is the value of one From in To or Peg.
+	Rate float64
+	// Start and End are the first and last date (YYYY-MM-DD) the override applies to; empty means open.
+	Start string
+	End   string
+}
+
+// Markup is a spread or fee applied on top of the provider rate for From/To.
+// One of From or To may be empty to match any currency, but not both.
+type Markup struct {
+	From    string
+	To      string
+	Percent float64
+	Pips    float64
+	// PipSize is the size of one pip in units of To; zero means 0.01 for the yen and 0.0001 otherwise.
+	PipSize float64
+	// Side is "buy" (default) or "sell".
+	Side string
+}
+
+// AggregateConfig selects period-average rates instead of daily rates.
+type AggregateConfig struct {
+	// Period is "weekly-average", "monthly-average" or "yearly-average".
+	Period string
+	// Method is "mean" or "median".
+	Method string
+	// Anchor is "first" or "last", the day of the period the rates are dated.
+	Anchor string
+}
+
+// DefaultProvider is the provider used when none is given.
+const DefaultProvider = exchange.DefaultProvider
+
+// NewProvider creates the built-in provider with the given name (e.g. "jsdelivr", "ecb" or "nbp").
+func NewProvider(name string, config ProviderConfig) (Provider, error) {
+	provider, err := exchange.NewProvider(name, exchange.ProviderConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	return builtinProvider{provider}, nil
+}
+
+// ProviderNames returns the names of all built-in providers.
+func ProviderNames() []string {
+	return exchange.ProviderNames()
+}
+
+// NewRateSet fetches the rates between the currencies on the date and applies the overrides.
+//
+// Parameters:
+//   - ctx: the context that cancels the provider requests.
+//   - provider: the source of the exchange rates.
+//   - currencies: the Firefly III currency codes.
+//   - date: the date (YYYY-MM-DD) or "latest".
+//   - mappings: currency code mappings between Firefly III and the provider, may be nil.
+//   - overrides: manual rates and pegs, may be nil.
+//   - concurrency: the maximum number of base currencies fetched at the same time.
+//
+// Returns:
+//   - The RateSet and an error if the rates cannot be fetched.
+func NewRateSet(ctx context.Context, provider Provider, currencies []string, date string, mappings []CurrencyMapping, overrides []Override, concurrency int) (*RateSet, error) {
+	api, err := exchange.NewApi(ctx, exchangeProvider(provider), currencies, date, exchangeMappings(mappings), exchangeOverrides(overrides), concurrency)
+	if err != nil {
+		return nil, err
+	}
+	return newRateSet(api), nil
+}
+
+// builtinProvider is a provider of the exchange package seen as a Provider.
+type builtinProvider struct {
+	provider exchange.Provider
+}
+
+func (p builtinProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p builtinProvider) FetchRates(ctx context.Context, currency string, date string) (ApiResponse, error) {
+	response, err := p.provider.FetchRates(ctx, currency, date)
+	return ApiResponse(response), err
+}
+
+func (p builtinProvider) Catalogue(ctx context.Context) (Catalogue, error) {
+	catalogue, err := p.provider.Catalogue(ctx)
+	return Catalogue(catalogue), err
+}
+
+// customProvider is a Provider implemented outside the module seen as a provider of the
+// exchange package. The optional interfaces are forwarded when the provider implements them.
+type customProvider struct {
+	provider Provider
+}
+
+func (p customProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p customProvider) FetchRates(ctx context.Context, currency string, date string) (exchange.ApiResponse, error) {
+	response, err := p.provider.FetchRates(ctx, currency, date)
+	return exchange.ApiResponse(response), err
+}
+
+func (p customProvider) Catalogue(ctx context.Context) (exchange.Catalogue, error) {
+	catalogue, err := p.provider.Catalogue(ctx)
+	return exchange.Catalogue(catalogue), err
+}
+
+func (p customProvider) CatalogueOn(ctx context.Context, date string) (exchange.Catalogue, error) {
+	dated, ok := p.provider.(DatedCatalogue)
+	if !ok {
+		return p.Catalogue(ctx)
+	}
+	catalogue, err := dated.CatalogueOn(ctx, date)
+	return exchange.Catalogue(catalogue), err
+}
+
+func (p customProvider) SelectCurrencies(codes []string) {
+	if selector, ok := p.provider.(CurrencySelector); ok {
+		selector.SelectCurrencies(codes)
+	}
+}
+
+// exchangeProvider returns the provider of the exchange package behind the provider.
+func exchangeProvider(provider Provider) exchange.Provider {
+	if builtin, ok := provider.(builtinProvider); ok {
+		return builtin.provider
+	}
+	return customProvider{provider}
+}
+
+func exchangeMappings(mappings []CurrencyMapping) []exchange.CurrencyMapping {
+	result := make([]exchange.CurrencyMapping, len(mappings))
+	for i, m := range mappings {
+		result[i] = exchange.CurrencyMapping(m)
+	}
+	return result
+}
+
+func exchangeOverrides(overrides []Override) []exchange.Override {
+	result := make([]exchange.Override, len(overrides))
+	for i, o := range overrides {
+		result[i] = exchange.Override(o)
+	}
+	return result
+}
+
+func exchangeMarkups(markups []Markup) []exchange.Markup {
+	result := make([]exchange.Markup, len(markups))
+	for i, m := range markups {
+		result[i] = exchange.Markup(m)
+	}
+	return result
+}
+
+func newRateSet(api *exchange.Api) *RateSet {
+	return &RateSet{Date: api.Date, Rates: newRates(api.Rates), Coverage: newCoverage(api.Coverage), api: api}
+}
+
+func newRates(rates []exchange.Rate) []Rate {
+	result := make([]Rate, len(rates))
+	for i, r := range rates {
+		result[i] = newRate(r)
+	}
+	return result
+}
+
+func newRate(r exchange.Rate) Rate {
+	return Rate{
+		From:     r.Pair.From.String(),
+		To:       r.Pair.To.String(),
+		Date:     r.Date,
+		Value:    r.Value,
+		Override: r.Override,
+		Adjusted: r.Adjusted,
+		Raw:      r.Raw,
+		Source:   r.Source,
+		Days:     r.Days,
+	}
+}
+
+func newCoverage(c *exchange.Coverage) *Coverage {
+	if c == nil {
+		return nil
+	}
+	return &Coverage{
+		Start:       c.Start,
+		End:         c.End,
+		Days:        append([]string{}, c.Days...),
+		Unpublished: append([]string{}, c.Unpublished...),
+		Missing:     append([]string{}, c.Missing...),
+		Pending:     append([]string{}, c.Pending...),
+		Complete:    c.Complete(),
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 Artur Taranchiev <artur.taranchiev@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package updater
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ewok/ffiii-rate-updater/internal/exchange"
+)
+
+// selectingProvider is a staticProvider with the optional interfaces.
+type selectingProvider struct {
+	staticProvider
+	selected []string
+}
+
+func (p *selectingProvider) SelectCurrencies(codes []string) {
+	p.selected = codes
+}
+
+func (p *selectingProvider) CatalogueOn(ctx context.Context, date string) (Catalogue, error) {
+	return Catalogue{"USD": "US Dollar", "EUR": "Euro"}, nil
+}
+
+func TestProviderBoundary(t *testing.T) {
+
+	// Built-in providers pass the boundary unwrapped
+	builtin, err := NewProvider("ecb", ProviderConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := exchangeProvider(builtin).(*exchange.ECB); !ok {
+		t.Errorf("built-in provider converted to %T, want *exchange.ECB", exchangeProvider(builtin))
+	}
+
+	// Custom providers keep their optional interfaces
+	custom := &selectingProvider{staticProvider: staticProvider{}}
+	provider := exchangeProvider(custom)
+	provider.(exchange.CurrencySelector).SelectCurrencies([]string{"usd", "eur"})
+	if !slices.Equal(custom.selected, []string{"usd", "eur"}) {
+		t.Errorf("selected %v, want usd and eur", custom.selected)
+	}
+	catalogue, err := provider.(exchange.DatedCatalogue).CatalogueOn(context.Background(), "2025-01-02")
+	if err != nil || !catalogue.Has("EUR") {
+		t.Errorf("dated catalogue %v, %v, want EUR", catalogue, err)
+	}
+
+	// Without them, the dated catalogue is the catalogue
+	if _, err := exchangeProvider(staticProvider{}).(exchange.DatedCatalogue).CatalogueOn(context.Background(), "2025-01-02"); err == nil {
+		t.Error("dated catalogue of a provider without one returned no error")
+	}
+}
+
+func TestRateSet(t *testing.T) {
+
+	provider := staticProvider{
+		"usd": {"eur": 0.9},
+		"eur": {"usd": 1.1},
+	}
+	rateSet, err := NewRateSet(context.Background(), provider, []string{"USD", "EUR"}, "2025-01-02", nil, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rateSet.Date != "2025-01-02" || len(rateSet.Rates) != 2 || rateSet.Coverage != nil {
+		t.Fatalf("rate set %+v, want two daily rates on 2025-01-02", *rateSet)
+	}
+
+	if err := rateSet.ApplyMarkups([]Markup{{From: "USD", To: "EUR", Percent: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	rate, err := rateSet.GetRate("USD", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.From != "USD" || rate.To != "EUR" || !rate.Adjusted || rate.Raw == 0 {
+		t.Errorf("USD/EUR = %+v, want the adjusted rate", rate)
+	}
+	for _, r := range rateSet.Rates {
+		if r.From == "USD" && r.Value != rate.Value {
+			t.Errorf("Rates holds %v, GetRate %v", r.Value, rate.Value)
+		}
+	}
+}